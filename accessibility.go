@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccessibleText returns the visible content as logical lines, for example to be read by a screen reader.
+// A row that is filled to the last column is joined with the next, as it is usually a line that wrapped.
+// Blanks at the end of each line and blank lines at the end of the screen are removed.
+func (t *Terminal) AccessibleText() string {
+	var lines []string
+	var line strings.Builder
+	rows := t.viewRows()
+	for i, row := range rows {
+		line.WriteString(rowsText([]gridRow{row}))
+		if i < len(rows)-1 && t.rowWraps(row) {
+			continue
+		}
+		lines = append(lines, line.String())
+		line.Reset()
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CursorDescription describes the cursor position, such as "row 3, column 12 of 24x80".
+// Rows and columns are counted from 1.
+func (t *Terminal) CursorDescription() string {
+	col := t.screen.cursorCol
+	if cols := int(t.screen.config.Columns); cols > 0 && col >= cols {
+		col = cols - 1 // waiting to wrap
+	}
+	desc := fmt.Sprintf("row %d, column %d of %dx%d", t.screen.cursorRow+1, col+1, t.screen.config.Rows, t.screen.config.Columns)
+	if t.screen.cursorHidden {
+		desc += ", cursor hidden"
+	}
+	return desc
+}
+
+// rowWraps returns true if the row is filled to the last column, so its text probably continues on the next row.
+func (t *Terminal) rowWraps(row gridRow) bool {
+	last := len(row.Cells) - 1
+	return last >= 0 && last == int(t.screen.config.Columns)-1 && row.Cells[last].Rune != ' ' && row.Cells[last].Rune != 0
+}
@@ -0,0 +1,21 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminal_AccessibleText(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+	term.handleOutput([]byte("hi\r\nwrapped  text\r\n"))
+
+	assert.Equal(t, "hi\nwrapped  text", term.AccessibleText())
+	assert.Equal(t, "row 5, column 1 of 5x5", term.CursorDescription())
+
+	term.handleOutput([]byte(esc("[1;5H") + "x" + esc("[?25l")))
+	assert.Equal(t, "row 1, column 5 of 5x5, cursor hidden", term.CursorDescription())
+}
@@ -0,0 +1,51 @@
+package terminal
+
+import "time"
+
+// activityInterval is the shortest time between two calls of the activity callback.
+const activityInterval = time.Second
+
+// SetActivityCallback sets a function to call when output arrives, for example to mark a background tab.
+// Calls are limited to one per second while output continues. The callback runs on a separate goroutine.
+// Pass nil to stop monitoring activity.
+func (t *Terminal) SetActivityCallback(callback func()) {
+	t.activityLock.Lock()
+	defer t.activityLock.Unlock()
+	t.activityCallback = callback
+	t.lastActivity = time.Time{}
+}
+
+// SetSilenceCallback sets a function to call when no output has arrived for the given duration,
+// such as a long build finishing. It is called once for each period of silence, on a separate goroutine.
+// Pass nil to stop monitoring silence.
+func (t *Terminal) SetSilenceCallback(d time.Duration, callback func()) {
+	t.activityLock.Lock()
+	defer t.activityLock.Unlock()
+	if t.silenceTimer != nil {
+		t.silenceTimer.Stop()
+		t.silenceTimer = nil
+	}
+	t.silenceDuration = d
+	if callback != nil {
+		t.silenceTimer = time.AfterFunc(d, callback)
+	}
+}
+
+// outputActivity is called when output has been received, to notify the activity and silence callbacks.
+func (t *Terminal) outputActivity() {
+	t.activityLock.Lock()
+	defer t.activityLock.Unlock()
+	if t.silenceTimer != nil {
+		t.silenceTimer.Reset(t.silenceDuration)
+	}
+	if t.activityCallback == nil {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(t.lastActivity) < activityInterval {
+		return
+	}
+	t.lastActivity = now
+	go t.activityCallback()
+}
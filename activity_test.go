@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityCallback(t *testing.T) {
+	term := New()
+	activity := make(chan bool, 2)
+	term.SetActivityCallback(func() {
+		activity <- true
+	})
+
+	term.outputActivity()
+	term.outputActivity() // within the activity interval, so no second callback
+
+	select {
+	case <-activity:
+	case <-time.After(time.Second):
+		t.Fatal("activity callback was not called")
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, len(activity))
+}
+
+func TestSilenceCallback(t *testing.T) {
+	term := New()
+	silence := make(chan time.Time, 1)
+	start := time.Now()
+	term.SetSilenceCallback(50*time.Millisecond, func() {
+		silence <- time.Now()
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	term.outputActivity() // output restarts the silence period
+	select {
+	case at := <-silence:
+		assert.GreaterOrEqual(t, at.Sub(start), 80*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("silence callback was not called")
+	}
+
+	term.SetSilenceCallback(0, nil)
+	term.outputActivity()
+}
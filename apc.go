@@ -10,18 +10,25 @@ type APCHandler func(*Terminal, string)
 
 var apcHandlers = map[string]func(*Terminal, string){}
 
-func (t *Terminal) handleAPC(code string) {
+func (s *Screen) handleAPC(code string) {
+	if strings.HasPrefix(code, "G") {
+		s.handleKittyGraphics(code[1:])
+		return
+	}
+
 	for apcCommand, handler := range apcHandlers {
 		if strings.HasPrefix(code, apcCommand) {
 			// Extract the argument from the code
 			arg := code[len(apcCommand):]
-			// Invoke the corresponding handler function
-			handler(t, arg)
+			// Invoke the corresponding handler function, handlers are passed a Terminal so need one to be shown
+			if s.runAPCHandler != nil {
+				s.runAPCHandler(handler, arg)
+			}
 			return
 		}
 	}
 
-	if t.debug {
+	if s.debug {
 		// Handle other APC sequences or log the received APC code
 		log.Println("Unrecognised APC", code)
 	}
@@ -5,9 +5,6 @@ import (
 	"log"
 	"strconv"
 	"strings"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/theme"
 )
 
 var (
@@ -41,100 +38,113 @@ var (
 	}
 )
 
-func (t *Terminal) handleColorEscape(message string) {
+func (s *Screen) handleColorEscape(message string) {
 	if message == "" || message == "0" {
-		t.currentBG = nil
-		t.currentFG = nil
-		t.bold = false
-		t.blinking = false
+		s.currentBG = nil
+		s.currentFG = nil
+		s.bold = false
+		s.blinking = false
+		s.overline = false
+		s.concealed = false
 		return
 	}
 	modes := strings.Split(message, ";")
 	for i := 0; i < len(modes); i++ {
 		mode := modes[i]
 		if mode == "" {
-			continue
+			mode = "0" // an empty parameter is a reset, leading zeros are trimmed so "CSI 0;31m" arrives as ";31"
 		}
 
 		if (mode == "38" || mode == "48") && i+1 < len(modes) {
 			nextMode := modes[i+1]
 			if nextMode == "5" && i+2 < len(modes) {
-				t.handleColorModeMap(mode, modes[i+2])
+				s.handleColorModeMap(mode, modes[i+2])
 				i += 2
 			} else if nextMode == "2" && i+4 < len(modes) {
-				t.handleColorModeRGB(mode, modes[i+2], modes[i+3], modes[i+4])
+				s.handleColorModeRGB(mode, modes[i+2], modes[i+3], modes[i+4])
 				i += 4
 			}
 		} else {
-			t.handleColorMode(mode)
+			s.handleColorMode(mode)
 		}
 	}
 }
 
-func (t *Terminal) handleColorMode(modeStr string) {
+func (s *Screen) handleColorMode(modeStr string) {
 	mode, err := strconv.Atoi(modeStr)
 	if err != nil {
-		fyne.LogError("Failed to parse color mode: "+modeStr, err)
+		log.Println("Failed to parse color mode:", modeStr, err)
 		return
 	}
 	switch mode {
 	case 0:
-		t.currentBG, t.currentFG = nil, nil
-		t.bold = false
-		t.blinking = false
+		s.currentBG, s.currentFG = nil, nil
+		s.bold = false
+		s.blinking = false
+		s.overline = false
+		s.concealed = false
 	case 1:
-		t.bold = true
+		s.bold = true
 	case 4, 24: //italic
 	case 5:
-		t.blinking = true
+		s.blinking = true
+	case 8:
+		s.concealed = true
 	case 7: // reverse
-		bg, fg := t.currentBG, t.currentFG
+		bg, fg := s.currentBG, s.currentFG
+		defaultFG, defaultBG := s.reverseColors()
 		if fg == nil {
-			t.currentBG = theme.ForegroundColor()
+			s.currentBG = defaultFG
 		} else {
-			t.currentBG = fg
+			s.currentBG = fg
 		}
 		if bg == nil {
-			t.currentFG = theme.DisabledButtonColor()
+			s.currentFG = defaultBG
 		} else {
-			t.currentFG = bg
+			s.currentFG = bg
 		}
 	case 27: // reverse off
-		bg, fg := t.currentBG, t.currentFG
+		bg, fg := s.currentBG, s.currentFG
 		if fg != nil {
-			t.currentBG = nil
+			s.currentBG = nil
 		} else {
-			t.currentBG = fg
+			s.currentBG = fg
 		}
 		if bg != nil {
-			t.currentFG = nil
+			s.currentFG = nil
 		} else {
-			t.currentFG = bg
+			s.currentFG = bg
 		}
+	case 28:
+		s.concealed = false
 	case 30, 31, 32, 33, 34, 35, 36, 37:
-		t.currentFG = basicColors[mode-30]
+		s.currentFG = basicColors[mode-30]
 	case 39:
-		t.currentFG = nil
+		s.currentFG = nil
 	case 40, 41, 42, 43, 44, 45, 46, 47:
-		t.currentBG = basicColors[mode-40]
+		s.currentBG = basicColors[mode-40]
 	case 49:
-		t.currentBG = nil
+		s.currentBG = nil
+	case 53:
+		s.overline = true
+	case 55:
+		s.overline = false
 	case 90, 91, 92, 93, 94, 95, 96, 97:
-		t.currentFG = brightColors[mode-90]
+		s.currentFG = brightColors[mode-90]
 	case 100, 101, 102, 103, 104, 105, 106, 107:
-		t.currentBG = brightColors[mode-100]
+		s.currentBG = brightColors[mode-100]
 	default:
-		if t.debug {
+		if s.debug {
 			log.Println("Unsupported graphics mode", mode)
 		}
 	}
 }
 
-func (t *Terminal) handleColorModeMap(mode, ids string) {
+func (s *Screen) handleColorModeMap(mode, ids string) {
 	var c color.Color
 	id, err := strconv.Atoi(ids)
 	if err != nil {
-		if t.debug {
+		if s.debug {
 			log.Println("Invalid color map ID", ids)
 		}
 		return
@@ -155,26 +165,81 @@ func (t *Terminal) handleColorModeMap(mode, ids string) {
 		inc := 256 / 24
 		y := id * inc
 		c = &color.Gray{uint8(y)}
-	} else if t.debug {
+	} else if s.debug {
 		log.Println("Invalid colour map ID", id)
 	}
 
 	if mode == "38" {
-		t.currentFG = c
+		s.currentFG = c
 	} else if mode == "48" {
-		t.currentBG = c
+		s.currentBG = c
 	}
 }
 
-func (t *Terminal) handleColorModeRGB(mode, rs, gs, bs string) {
+func (s *Screen) handleColorModeRGB(mode, rs, gs, bs string) {
 	r, _ := strconv.Atoi(rs)
 	g, _ := strconv.Atoi(gs)
 	b, _ := strconv.Atoi(bs)
 	c := &color.RGBA{uint8(r), uint8(g), uint8(b), 255}
 
 	if mode == "38" {
-		t.currentFG = c
+		s.currentFG = c
 	} else if mode == "48" {
-		t.currentBG = c
+		s.currentBG = c
+	}
+}
+
+// getBrightColor returns the bright version of one of the basic colours.
+// Any other colour is returned unchanged.
+func getBrightColor(c color.Color) color.Color {
+	for i, basic := range basicColors {
+		if c == basic {
+			return brightColors[i]
+		}
+	}
+	return c
+}
+
+// reverseColors returns the default text and background colours, which reverse video swaps
+// when no colour has been set.
+func (s *Screen) reverseColors() (fg, bg color.Color) {
+	if s.defaultColors != nil {
+		return s.defaultColors()
+	}
+	return color.White, color.Black
+}
+
+// SetBoldIsBright sets whether bold text in one of the basic colours is drawn using the bright version of that colour.
+// The default is true, matching the common xterm configuration.
+func (s *Screen) SetBoldIsBright(bright bool) {
+	s.boldIsBright = bright
+}
+
+// SetBoldIsBright sets whether bold text in one of the basic colours is drawn using the bright version of that colour.
+// The default is true, matching the common xterm configuration.
+func (t *Terminal) SetBoldIsBright(bright bool) {
+	t.screen.SetBoldIsBright(bright)
+}
+
+// CellStyle describes the attributes of a cell, or those that will be used for the next characters printed.
+type CellStyle struct {
+	// Foreground and Background are the colours requested by the application, nil means the default colour.
+	Foreground, Background color.Color
+	Bold, Blinking         bool
+	Overline, Concealed    bool
+	// Protected characters are not removed by a selective erase.
+	Protected bool
+}
+
+// CurrentStyle returns the attributes that have been set using SGR (and DECSCA) sequences.
+func (t *Terminal) CurrentStyle() CellStyle {
+	return t.screen.CurrentStyle()
+}
+
+func (s *Screen) currentStyle() CellStyle {
+	return CellStyle{
+		Foreground: s.currentFG, Background: s.currentBG,
+		Bold: s.bold, Blinking: s.blinking, Overline: s.overline, Concealed: s.concealed,
+		Protected: s.protected,
 	}
 }
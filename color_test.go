@@ -29,15 +29,15 @@ func testColor(t *testing.T, tests map[string]struct {
 			terminal.handleOutput([]byte(test.inputSeq))
 
 			// Verify the actual results match the expected results
-			if !reflect.DeepEqual(terminal.currentFG, test.expectedFg) {
-				t.Errorf("Foreground color mismatch. Got %v, expected %v", terminal.currentFG, test.expectedFg)
+			if !reflect.DeepEqual(terminal.screen.currentFG, test.expectedFg) {
+				t.Errorf("Foreground color mismatch. Got %v, expected %v", terminal.screen.currentFG, test.expectedFg)
 			}
 
-			if !reflect.DeepEqual(terminal.currentBG, test.expectedBg) {
-				t.Errorf("Background color mismatch. Got %v, expected %v", terminal.currentBG, test.expectedBg)
+			if !reflect.DeepEqual(terminal.screen.currentBG, test.expectedBg) {
+				t.Errorf("Background color mismatch. Got %v, expected %v", terminal.screen.currentBG, test.expectedBg)
 			}
-			if terminal.bold != test.expectedBold {
-				t.Errorf("Bold flag mismatch. Got %v, expected %v", terminal.bold, test.expectedBold)
+			if terminal.screen.bold != test.expectedBold {
+				t.Errorf("Bold flag mismatch. Got %v, expected %v", terminal.screen.bold, test.expectedBold)
 			}
 		})
 	}
@@ -60,8 +60,8 @@ func TestHandleOutput_Text(t *testing.T) {
 			terminal := New()
 			terminal.handleOutput([]byte(test.inputSeq))
 
-			if terminal.bold != test.expectBold {
-				t.Errorf("Bold flag mismatch. Got %v, expected %v", terminal.bold, test.expectBold)
+			if terminal.screen.bold != test.expectBold {
+				t.Errorf("Bold flag mismatch. Got %v, expected %v", terminal.screen.bold, test.expectBold)
 			}
 		})
 	}
@@ -928,5 +928,84 @@ func TestHandleOutput_BufferCutoff(t *testing.T) {
 			},
 		},
 	}
-	assert.Equal(t, tg.Rows, term.content.Rows)
+	assert.Equal(t, 1, len(term.grid.Rows))
+	assert.Equal(t, tg.Rows[0].Cells, term.grid.Rows[0].Cells[:4])
+	for _, c := range term.grid.Rows[0].Cells[4:] {
+		assert.Equal(t, widget.TextGridCell{Rune: ' '}, c) // padded to the terminal width
+	}
+}
+
+func TestHandleOutput_BoldIsBright(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte(esc("[1;31mA") + esc("[0;31mB") + esc("[1;38;5;100mC")))
+	row := term.screen.content.Row(0)
+	assert.Equal(t, brightColors[1], row.Cells[0].Style.Foreground)
+	assert.Equal(t, basicColors[1], row.Cells[1].Style.Foreground)
+	assert.Equal(t, &color.RGBA{135, 135, 0, 255}, row.Cells[2].Style.Foreground)
+
+	term.SetBoldIsBright(false)
+	term.handleOutput([]byte(esc("[1;31mD")))
+	assert.Equal(t, basicColors[1], term.screen.content.Row(0).Cells[3].Style.Foreground)
+	assert.Equal(t, basicColors[1], term.screen.currentFG)
+}
+
+func TestHandleOutput_ResetParameters(t *testing.T) {
+	term := New()
+	term.handleOutput([]byte(esc("[1;0;31m")))
+	assert.False(t, term.screen.bold)
+	assert.Equal(t, basicColors[1], term.screen.currentFG)
+
+	term.handleOutput([]byte(esc("[1;32m") + esc("[0;31m")))
+	assert.False(t, term.screen.bold)
+	assert.Equal(t, basicColors[1], term.screen.currentFG)
+
+	term.handleOutput([]byte(esc("[1;32m") + esc("[;m")))
+	assert.False(t, term.screen.bold)
+	assert.Nil(t, term.screen.currentFG)
+
+	term.handleOutput([]byte(esc("[1;32;m")))
+	assert.False(t, term.screen.bold)
+	assert.Nil(t, term.screen.currentFG)
+
+	term.handleOutput([]byte(esc("[1;;32m")))
+	assert.False(t, term.screen.bold)
+	assert.Equal(t, basicColors[2], term.screen.currentFG)
+}
+
+func TestTerminal_CurrentStyle(t *testing.T) {
+	term := New()
+	assert.Equal(t, CellStyle{}, term.CurrentStyle())
+
+	term.handleOutput([]byte(esc("[1;5;31;42m")))
+	assert.Equal(t, CellStyle{Foreground: basicColors[1], Background: basicColors[2], Bold: true, Blinking: true},
+		term.CurrentStyle())
+
+	term.handleOutput([]byte(esc("[0m") + esc("[1\"q")))
+	assert.Equal(t, CellStyle{Protected: true}, term.CurrentStyle())
+}
+
+func TestHandleOutput_Overline(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte(esc("[53mA") + esc("[55mB") + esc("[53mC") + esc("[0mD")))
+	row := term.screen.content.Row(0)
+	assert.True(t, row.Cells[0].Style.Overline)
+	assert.False(t, row.Cells[1].Style.Overline)
+	assert.True(t, row.Cells[2].Style.Overline)
+	assert.False(t, row.Cells[3].Style.Overline)
+}
+
+func TestHandleOutput_Conceal(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte(esc("[8mpw") + esc("[28m!")))
+	row := term.screen.content.Row(0)
+	assert.True(t, row.Cells[0].Style.Concealed)
+	assert.True(t, row.Cells[1].Style.Concealed)
+	assert.False(t, row.Cells[2].Style.Concealed)
+	assert.Equal(t, "pw!", term.Text())
 }
@@ -0,0 +1,134 @@
+package terminal
+
+import (
+	"fyne.io/fyne/v2"
+)
+
+// EnterCopyMode starts keyboard selection. While in copy mode key presses are not sent to the terminal,
+// instead the arrow keys or h, j, k and l move the selection cursor, Space starts a selection,
+// Enter or y copies the selected text and Escape or q leaves copy mode.
+func (t *Terminal) EnterCopyMode() {
+	if t.copyMode {
+		return
+	}
+	if t.hasSelectedText() {
+		t.clearSelectedText()
+	}
+
+	t.copyMode = true
+	t.copyAnchored = false
+	t.copyCursor = position{Col: t.screen.cursorCol + 1, Row: t.screen.cursorRow + 1}
+	t.clampCopyCursor()
+	t.updateCopySelection()
+}
+
+// ExitCopyMode leaves keyboard selection, clearing any selection that was not copied.
+func (t *Terminal) ExitCopyMode() {
+	if !t.copyMode {
+		return
+	}
+
+	t.clearSelectedText()
+	t.selStart, t.selEnd = nil, nil
+	t.copyMode = false
+	t.copyAnchored = false
+}
+
+// InCopyMode returns true if the terminal is currently in keyboard selection mode.
+func (t *Terminal) InCopyMode() bool {
+	return t.copyMode
+}
+
+// SetCopyModeShortcut sets the shortcut that enters copy mode, replacing the default.
+// The default is Ctrl+Shift+X, or Cmd+Shift+X on macOS. Pass nil to remove the shortcut.
+func (t *Terminal) SetCopyModeShortcut(s fyne.Shortcut) {
+	if t.copyModeShortcut != nil {
+		t.ShortcutHandler.RemoveShortcut(t.copyModeShortcut)
+	}
+	t.copyModeShortcut = s
+	if s != nil {
+		t.ShortcutHandler.AddShortcut(s, func(_ fyne.Shortcut) {
+			t.EnterCopyMode()
+		})
+	}
+}
+
+func (t *Terminal) typeCopyModeKey(e *fyne.KeyEvent) {
+	switch e.Name {
+	case fyne.KeyLeft, fyne.KeyH:
+		t.moveCopyCursor(0, -1)
+	case fyne.KeyRight, fyne.KeyL:
+		t.moveCopyCursor(0, 1)
+	case fyne.KeyUp, fyne.KeyK:
+		t.moveCopyCursor(-1, 0)
+	case fyne.KeyDown, fyne.KeyJ:
+		t.moveCopyCursor(1, 0)
+	case fyne.KeySpace:
+		t.copyAnchored = true
+		t.updateCopySelection()
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeyY:
+		if c := t.clipboard(); c != nil {
+			t.copySelectedText(c)
+		}
+		t.ExitCopyMode()
+	case fyne.KeyEscape, fyne.KeyQ:
+		t.ExitCopyMode()
+	}
+}
+
+func (t *Terminal) moveCopyCursor(rows, cols int) {
+	t.copyCursor.Row += rows
+	t.copyCursor.Col += cols
+	t.clampCopyCursor()
+	t.followCopyCursor()
+	t.updateCopySelection()
+}
+
+// clampCopyCursor keeps the copy cursor within the scrollback and the screen.
+// Rows are counted from the top of the view, so rows in the scrollback above the view are less than 1.
+func (t *Terminal) clampCopyCursor() {
+	if t.copyCursor.Col > int(t.screen.config.Columns) {
+		t.copyCursor.Col = int(t.screen.config.Columns)
+	}
+	if t.copyCursor.Col < 1 {
+		t.copyCursor.Col = 1
+	}
+
+	top := 1
+	if !t.screen.altScreen {
+		top -= len(t.screen.scrollback) - t.scrollOffset
+	}
+	if bottom := int(t.screen.config.Rows) + t.scrollOffset; t.copyCursor.Row > bottom {
+		t.copyCursor.Row = bottom
+	}
+	if t.copyCursor.Row < top {
+		t.copyCursor.Row = top
+	}
+}
+
+// followCopyCursor scrolls the view so that the copy cursor is shown.
+func (t *Terminal) followCopyCursor() {
+	lines := 0
+	if t.copyCursor.Row < 1 {
+		lines = 1 - t.copyCursor.Row
+	} else if rows := int(t.screen.config.Rows); t.copyCursor.Row > rows {
+		lines = rows - t.copyCursor.Row
+	}
+	t.copyCursor.Row += t.scrollView(lines) // the selection moves with the content
+}
+
+// updateCopySelection highlights from the selection anchor to the copy cursor,
+// or just the cursor cell if no selection has been started.
+func (t *Terminal) updateCopySelection() {
+	if t.hasSelectedText() {
+		t.clearHighlight()
+	}
+
+	end := t.copyCursor
+	if !t.copyAnchored || t.selStart == nil {
+		start := t.copyCursor
+		t.selStart = &start
+	}
+	t.selEnd = &end
+	t.highlightSelectedText()
+}
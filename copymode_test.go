@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyMode(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("Hello\r\nWorld"))
+	term.screen.moveCursor(0, 0)
+
+	term.EnterCopyMode()
+	assert.True(t, term.InCopyMode())
+	term.TypedRune('x') // not sent to the terminal
+	assert.Equal(t, "H", term.SelectedText())
+
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyRight})
+	assert.Equal(t, "e", term.SelectedText())
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeySpace})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyL})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyL})
+	assert.Equal(t, "ell", term.SelectedText())
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyJ})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyJ}) // clamped to the last row
+	assert.Equal(t, "ello\nWorl", term.SelectedText())
+
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+	assert.False(t, term.InCopyMode())
+	assert.False(t, term.hasSelectedText())
+}
+
+func TestCopyMode_Scrollback(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("one\r\ntwo\r\nthree\r\nfour"))
+	term.screen.moveCursor(0, 0)
+
+	term.EnterCopyMode()
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeySpace})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyK})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyK})
+	assert.Equal(t, 2, term.scrollOffset) // the view follows the cursor into the scrollback
+	assert.Equal(t, 1, term.copyCursor.Row)
+	assert.Equal(t, "one\ntwo\nt", term.SelectedText())
+
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyK}) // clamped to the first line of scrollback
+	assert.Equal(t, 2, term.scrollOffset)
+	assert.Equal(t, "one\ntwo\nt", term.SelectedText())
+
+	for i := 0; i < 5; i++ {
+		term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyJ})
+	}
+	assert.Equal(t, 0, term.scrollOffset)
+	assert.Equal(t, 2, term.copyCursor.Row) // clamped to the last row of the screen
+	assert.Equal(t, "three\nf", term.SelectedText())
+}
@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"log"
+	"strings"
+)
+
+const dcsTmuxPrefix = "tmux;"
+
+// DCSHandler handles a DCS (device control string) for the given terminal.
+type DCSHandler func(*Terminal, string)
+
+func (s *Screen) handleDCS(code string) {
+	prefix := ""
+	var handler func(string)
+	for dcsCommand, h := range s.dcsHandlers {
+		if strings.HasPrefix(code, dcsCommand) && (handler == nil || len(dcsCommand) > len(prefix)) {
+			prefix = dcsCommand
+			handler = h
+		}
+	}
+	if handler != nil {
+		handler(code[len(prefix):])
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(code, dcsTmuxPrefix):
+		// tmux wraps sequences for the outer terminal and doubles each ESC within them
+		s.handlePassthrough(strings.ReplaceAll(code[len(dcsTmuxPrefix):], "\x1b\x1b", "\x1b"))
+		return
+	case strings.HasPrefix(code, "\x1b"):
+		// screen wraps sequences for the outer terminal without any prefix
+		s.handlePassthrough(code)
+		return
+	}
+
+	if s.debug {
+		log.Println("Unrecognised DCS", code)
+	}
+}
+
+// RegisterDCSHandler registers a handler for device control strings that start with the given prefix.
+// The handler is passed the remainder of the string after the prefix.
+// If more than one prefix matches then the longest is used.
+func (t *Terminal) RegisterDCSHandler(prefix string, handler DCSHandler) {
+	if t.screen.dcsHandlers == nil {
+		t.screen.dcsHandlers = make(map[string]func(string))
+	}
+	t.screen.dcsHandlers[prefix] = func(data string) {
+		handler(t, data)
+	}
+}
+
+// handlePassthrough processes output that was wrapped by a terminal multiplexer.
+// The wrapped data is parsed with its own state so that it cannot disturb the state of the enclosing stream,
+// that state is kept so that a sequence split across more than one passthrough is handled once it is complete.
+func (s *Screen) handlePassthrough(data string) {
+	outer := s.state
+	if outer.passthrough == nil {
+		outer.passthrough = &parseState{esc: noEscape}
+	}
+
+	s.state = outer.passthrough
+	_ = s.parseOutput([]byte(data)) // the string holds whole characters, so nothing is left over
+	s.state = outer
+}
@@ -0,0 +1,83 @@
+package terminal
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDCS(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"short prefix": {
+			input:    "\x1bP1$rm\x1b\\",
+			expected: "1:$rm",
+		},
+		"longest prefix": {
+			input:    "\x1bP1$qm\x1b\\",
+			expected: "1$q:m",
+		},
+		"unmatched": {
+			input:    "\x1bPzz\x1b\\",
+			expected: "",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := ""
+			term := New()
+			term.Resize(fyne.NewSize(50, 50))
+			term.RegisterDCSHandler("1", func(_ *Terminal, s string) {
+				got = "1:" + s
+			})
+			term.RegisterDCSHandler("1$q", func(_ *Terminal, s string) {
+				got = "1$q:" + s
+			})
+			term.handleOutput([]byte(testCase.input + "ok"))
+
+			assert.Equal(t, testCase.expected, got)
+			assert.Equal(t, "ok", term.screen.content.Text())
+		})
+	}
+}
+
+func TestDCS_TmuxPassthrough(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 2
+	data := []byte("\x1bPtmux;\x1b\x1b[31m\x1b\\A")
+
+	for i := range data { // split at every position to check that reads can end mid sequence
+		term.handleOutput(data[i : i+1])
+	}
+	assert.Equal(t, "A", term.screen.content.Text())
+	assert.Equal(t, basicColors[1], term.screen.content.Row(0).Cells[0].Style.Foreground)
+	assert.False(t, term.screen.state.dcs)
+}
+
+func TestDCS_TmuxPassthroughSplit(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte("\x1bPtmux;\x1b\x1b[3\x1b\\")) // a sequence split across two passthroughs
+	term.handleOutput([]byte("\x1bPtmux;1m世\x1b\\A"))
+
+	assert.Equal(t, "世A", term.screen.content.Text())
+	assert.Equal(t, basicColors[1], term.screen.content.Row(0).Cells[0].Style.Foreground)
+	assert.False(t, term.screen.state.dcs)
+}
+
+func TestDCS_ScreenPassthrough(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte("\x1bP\x1b[32m\x1b\\B"))
+
+	assert.Equal(t, "B", term.screen.content.Text())
+	assert.Equal(t, basicColors[2], term.screen.content.Row(0).Cells[0].Style.Foreground)
+	assert.False(t, term.screen.state.dcs)
+}
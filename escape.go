@@ -5,11 +5,9 @@ import (
 	"log"
 	"strconv"
 	"strings"
-
-	"fyne.io/fyne/v2/widget"
 )
 
-var escapes = map[rune]func(*Terminal, string){
+var escapes = map[rune]func(*Screen, string){
 	'@': escapeInsertChars,
 	'A': escapeMoveCursorUp,
 	'B': escapeMoveCursorDown,
@@ -22,6 +20,7 @@ var escapes = map[rune]func(*Terminal, string){
 	'h': escapePrivateModeOn,
 	'L': escapeInsertLines,
 	'l': escapePrivateModeOff,
+	'M': escapeDeleteLines,
 	'm': escapeColorMode,
 	'J': escapeEraseInScreen,
 	'K': escapeEraseInLine,
@@ -30,298 +29,585 @@ var escapes = map[rune]func(*Terminal, string){
 	's': escapeSaveCursor,
 	'u': escapeRestoreCursor,
 	'i': escapePrinterMode,
+	'c': escapeDeviceAttributes,
+	't': escapeWindowOps,
+	'T': escapeResetTitleModes,
+}
+
+// intermediateEscapes are control sequences that have intermediate characters before the final one.
+// They are keyed by the intermediate characters followed by the final character.
+var intermediateEscapes = map[string]func(*Screen, string){
+	" q":  escapeCursorStyle,
+	"\"q": escapeCharacterProtection,
+	"'}":  escapeInsertColumns,
+	"'~":  escapeDeleteColumns,
 }
 
-func (t *Terminal) handleEscape(code string) {
+func (s *Screen) handleEscape(code string) {
 	code = trimLeftZeros(code)
 	if code == "" {
 		return
 	}
 
+	params := code[:len(code)-1]
+	i := strings.IndexFunc(params, isIntermediate)
+	if i == -1 {
+		i = len(params)
+	}
+	if handler, ok := s.csiHandlers[code[i:]]; ok {
+		handler(params[:i])
+		return
+	}
+
+	if i < len(params) {
+		if esc, ok := intermediateEscapes[code[i:]]; ok {
+			esc(s, params[:i])
+		} else if s.debug {
+			log.Println("Unrecognised Escape:", code)
+		}
+		return
+	}
+
 	runes := []rune(code)
-	if esc, ok := escapes[runes[len(code)-1]]; ok {
-		esc(t, code[:len(code)-1])
-	} else if t.debug {
+	if esc, ok := escapes[runes[len(runes)-1]]; ok {
+		esc(s, params)
+	} else if s.debug {
 		log.Println("Unrecognised Escape:", code)
 	}
 }
 
-func (t *Terminal) clearScreen() {
-	t.moveCursor(0, 0)
-	t.clearScreenFromCursor()
-}
+// CSIHandler handles a CSI control sequence for the given terminal, it is passed the parameters of the sequence.
+type CSIHandler func(*Terminal, string)
 
-func (t *Terminal) clearScreenFromCursor() {
-	row := t.content.Row(t.cursorRow)
-	from := t.cursorCol
-	if t.cursorCol > len(row.Cells) {
-		from = len(row.Cells)
+// RegisterCSIHandler registers a handler for CSI sequences ending with the final character and
+// having the given intermediate characters (which may be empty).
+// Handlers registered here take precedence over the built in sequences for this terminal.
+func (t *Terminal) RegisterCSIHandler(final rune, intermediates string, handler CSIHandler) {
+	if t.screen.csiHandlers == nil {
+		t.screen.csiHandlers = make(map[string]func(string))
 	}
-	if from > 0 {
-		t.content.SetRow(t.cursorRow, widget.TextGridRow{Cells: row.Cells[:from]})
-	} else {
-		t.content.SetRow(t.cursorRow, widget.TextGridRow{})
+	t.screen.csiHandlers[intermediates+string(final)] = func(params string) {
+		handler(t, params)
 	}
+}
 
-	for i := t.cursorRow + 1; i < len(t.content.Rows); i++ {
-		t.content.SetRow(i, widget.TextGridRow{})
-	}
+func isIntermediate(r rune) bool {
+	return r >= 0x20 && r <= 0x2f
 }
 
-func (t *Terminal) clearScreenToCursor() {
-	row := t.content.Row(t.cursorRow)
-	cells := make([]widget.TextGridCell, t.cursorCol)
-	if t.cursorCol < len(row.Cells) {
-		cells = append(cells, row.Cells[t.cursorCol:]...)
+func (s *Screen) clearScreen() {
+	s.moveCursor(0, 0)
+	s.clearScreenFromCursor()
+	s.clearScreenImages()
+}
+
+func (s *Screen) clearScreenFromCursor() {
+	s.eraseCells(s.cursorRow, s.cursorCol, int(s.config.Columns))
+
+	for i := s.cursorRow + 1; i < len(s.content.Rows); i++ {
+		s.content.SetRow(i, s.blankRow())
 	}
-	t.content.SetRow(t.cursorRow, widget.TextGridRow{Cells: cells})
+}
+
+func (s *Screen) clearScreenToCursor() {
+	s.ensureRow(s.cursorRow)
+	s.eraseCells(s.cursorRow, 0, s.cursorCol)
 
-	for i := 0; i < t.cursorRow-1; i++ {
-		t.content.SetRow(i, widget.TextGridRow{})
+	for i := 0; i < s.cursorRow-1; i++ {
+		s.content.SetRow(i, s.blankRow())
 	}
 }
 
-func (t *Terminal) handleVT100(code string) {
-	switch code {
-	case "(A":
-		t.g0Charset = charSetAlternate
-	case ")A":
-		t.g1Charset = charSetAlternate
-	case "(B":
-		t.g0Charset = charSetANSII
-	case ")B":
-		t.g1Charset = charSetANSII
-	case "(0":
-		t.g0Charset = charSetDECSpecialGraphics
-	case ")0":
-		t.g1Charset = charSetDECSpecialGraphics
-	default:
-		if t.debug {
-			log.Println("Unhandled VT100:", code)
+func (s *Screen) handleVT100(code string) {
+	runes := []rune(code)
+	if set, ok := charSetDesignators[runes[len(runes)-1]]; ok && len(runes) == 2 {
+		switch runes[0] {
+		case '(':
+			s.g0Charset = set
+			return
+		case ')':
+			s.g1Charset = set
+			return
 		}
 	}
+
+	if s.debug {
+		log.Println("Unhandled VT100:", code)
+	}
+}
+
+func (s *Screen) moveCursor(row, col int) {
+	s.placeCursor(row, col, false)
 }
 
-func (t *Terminal) moveCursor(row, col int) {
-	if t.config.Columns == 0 || t.config.Rows == 0 {
+// moveCursorToCell moves the cursor like moveCursor, but if the position is the second half of a
+// double width character the cursor is placed on the character itself. This is used for sequences that
+// address a cell, rather than those that move by a number of columns.
+func (s *Screen) moveCursorToCell(row, col int) {
+	s.placeCursor(row, col, true)
+}
+
+func (s *Screen) placeCursor(row, col int, snap bool) {
+	if s.config.Columns == 0 || s.config.Rows == 0 {
 		return
 	}
 	if col < 0 {
 		col = 0
-	} else if col >= int(t.config.Columns) {
-		col = int(t.config.Columns) - 1
+	} else if col >= int(s.config.Columns) {
+		col = int(s.config.Columns) - 1
 	}
 
+	if s.originMode {
+		if row < s.scrollTop {
+			row = s.scrollTop
+		} else if row > s.scrollBottom {
+			row = s.scrollBottom
+		}
+	}
 	if row < 0 {
 		row = 0
-	} else if row >= int(t.config.Rows) {
-		row = int(t.config.Rows) - 1
+	} else if row >= int(s.config.Rows) {
+		row = int(s.config.Rows) - 1
+	}
+	if snap && s.isWideCharPadding(row, col) {
+		col--
 	}
 
-	t.cursorCol = col
-	t.cursorRow = row
+	s.cursorCol = col
+	s.cursorRow = row
 
-	if t.cursorMoved != nil {
-		t.cursorMoved()
+	s.sendEvent(TerminalEvent{Type: EventCursorMoved, Row: row, Col: col})
+	if s.cursorMoved != nil {
+		s.cursorMoved()
 	}
 }
 
-func escapeColorMode(t *Terminal, msg string) {
-	t.handleColorEscape(msg)
+// isWideCharPadding returns true if the cell is the second half of a double width character.
+func (s *Screen) isWideCharPadding(row, col int) bool {
+	if row < 0 || row >= len(s.content.Rows) || col <= 0 {
+		return false
+	}
+	cells := s.content.Rows[row].Cells
+	return col < len(cells) && cells[col].Rune == wideCharPadding
+}
+
+// splitWideChar blanks both halves of a double width character that spans columns col-1 and col, so that
+// erasing, inserting or deleting cells from col cannot leave half of the character behind.
+func (s *Screen) splitWideChar(row, col int) {
+	if !s.isWideCharPadding(row, col) {
+		return
+	}
+	cells := s.content.Rows[row].Cells
+	blank := s.blankCell()
+	cells[col-1], cells[col] = blank, blank
+	s.content.MarkRowDirty(row)
 }
 
-func escapeDeleteChars(t *Terminal, msg string) {
+// cropWideChar blanks a double width character in the last column whose second half has been pushed off the row.
+func (s *Screen) cropWideChar(row int) {
+	cells := s.content.Rows[row].Cells
+	if last := len(cells) - 1; last >= 0 && runeWidth(cells[last].Rune) == 2 {
+		cells[last] = s.blankCell()
+	}
+}
+
+func escapeColorMode(s *Screen, msg string) {
+	s.handleColorEscape(msg)
+}
+
+func escapeCursorStyle(s *Screen, msg string) {
+	style, _ := strconv.Atoi(msg)
+	s.cursorBlinks = style%2 == 1 // odd styles blink, even and the default are steady
+	switch style {
+	case 0:
+		s.cursorShape = s.defaultCursorShape
+	case 1, 2:
+		s.cursorShape = CursorShapeBlock
+	case 3, 4:
+		s.cursorShape = CursorShapeUnderline
+	case 5, 6:
+		s.cursorShape = CursorShapeCaret
+	default:
+		if s.debug {
+			log.Println("Unknown cursor style", style)
+		}
+	}
+}
+
+func escapeDeleteChars(s *Screen, msg string) {
 	i, _ := strconv.Atoi(msg)
 	if i == 0 {
 		i = 1
 	}
-	right := t.cursorCol + i
+	if s.cursorRow >= len(s.content.Rows) {
+		return
+	}
+	s.padRow(s.cursorRow)
+
+	cells := s.content.Rows[s.cursorRow].Cells
+	if s.cursorCol >= len(cells) {
+		return
+	}
+	s.splitWideChar(s.cursorRow, s.cursorCol)
+	s.splitWideChar(s.cursorRow, s.cursorCol+i)
+	moved := 0
+	if right := s.cursorCol + i; right < len(cells) {
+		moved = copy(cells[s.cursorCol:], cells[right:])
+	}
+	s.eraseCells(s.cursorRow, s.cursorCol+moved, len(cells))
+}
 
-	row := t.content.Row(t.cursorRow)
-	cells := row.Cells[:t.cursorCol]
-	if right < len(row.Cells) {
-		cells = append(cells, row.Cells[right:]...)
+func escapeEraseInLine(s *Screen, msg string) {
+	if strings.HasPrefix(msg, "?") {
+		escapeSelectiveEraseInLine(s, msg[1:])
+		return
 	}
 
-	t.content.SetRow(t.cursorRow, widget.TextGridRow{Cells: cells})
+	mode, _ := strconv.Atoi(msg)
+	switch mode {
+	case 0:
+		s.eraseCells(s.cursorRow, s.cursorCol, int(s.config.Columns))
+	case 1:
+		s.eraseCells(s.cursorRow, 0, s.cursorCol)
+	case 2:
+		s.eraseCells(s.cursorRow, 0, int(s.config.Columns))
+	}
 }
 
-func escapeEraseInLine(t *Terminal, msg string) {
+// escapeSelectiveEraseInLine handles DECSEL, which erases like EL but leaves protected cells.
+func escapeSelectiveEraseInLine(s *Screen, msg string) {
 	mode, _ := strconv.Atoi(msg)
 	switch mode {
 	case 0:
-		row := t.content.Row(t.cursorRow)
-		if t.cursorCol >= len(row.Cells) {
-			return
-		}
-		t.content.SetRow(t.cursorRow, widget.TextGridRow{Cells: row.Cells[:t.cursorCol]})
+		s.eraseUnprotectedCells(s.cursorRow, s.cursorCol, int(s.config.Columns))
 	case 1:
-		row := t.content.Row(t.cursorRow)
-		if t.cursorCol >= len(row.Cells) {
-			return
-		}
-		cells := make([]widget.TextGridCell, t.cursorCol)
-		t.content.SetRow(t.cursorRow, widget.TextGridRow{Cells: append(cells, row.Cells[t.cursorCol:]...)})
+		s.eraseUnprotectedCells(s.cursorRow, 0, s.cursorCol+1)
 	case 2:
-		row := t.content.Row(t.cursorRow)
-		if t.cursorCol >= len(row.Cells) {
-			return
-		}
-		cells := make([]widget.TextGridCell, len(row.Cells))
-		t.content.SetRow(t.cursorRow, widget.TextGridRow{Cells: cells})
+		s.eraseUnprotectedCells(s.cursorRow, 0, int(s.config.Columns))
 	}
 }
 
-func escapeEraseInScreen(t *Terminal, msg string) {
+func escapeEraseInScreen(s *Screen, msg string) {
+	if strings.HasPrefix(msg, "?") {
+		escapeSelectiveEraseInScreen(s, msg[1:])
+		return
+	}
+
 	mode, _ := strconv.Atoi(msg)
 	switch mode {
 	case 0:
-		t.clearScreenFromCursor()
+		s.clearScreenFromCursor()
 	case 1:
-		t.clearScreenToCursor()
+		s.clearScreenToCursor()
 	case 2:
-		t.clearScreen()
+		s.clearScreen()
 	}
 }
 
-func escapeInsertChars(t *Terminal, msg string) {
+// escapeSelectiveEraseInScreen handles DECSED, which erases like ED but leaves protected cells.
+func escapeSelectiveEraseInScreen(s *Screen, msg string) {
+	first, last := 0, len(s.content.Rows)-1
+	mode, _ := strconv.Atoi(msg)
+	switch mode {
+	case 0:
+		s.eraseUnprotectedCells(s.cursorRow, s.cursorCol, int(s.config.Columns))
+		first = s.cursorRow + 1
+	case 1:
+		s.eraseUnprotectedCells(s.cursorRow, 0, s.cursorCol+1)
+		last = s.cursorRow - 1
+	case 2:
+	default:
+		return
+	}
+
+	for i := first; i <= last; i++ {
+		s.eraseUnprotectedCells(i, 0, int(s.config.Columns))
+	}
+}
+
+// escapeCharacterProtection handles DECSCA, which sets whether following characters are protected
+// from selective erase.
+func escapeCharacterProtection(s *Screen, msg string) {
+	mode, _ := strconv.Atoi(msg)
+	s.protected = mode == 1
+}
+
+func escapeInsertChars(s *Screen, msg string) {
 	chars, _ := strconv.Atoi(msg)
 	if chars == 0 {
 		chars = 1
 	}
+	s.insertCells(chars)
+}
 
-	newCells := make([]widget.TextGridCell, chars)
-	cellStyle := &widget.CustomTextGridStyle{FGColor: t.currentFG, BGColor: t.currentBG}
+// insertCells adds blank cells at the cursor, moving the rest of the row to the right.
+func (s *Screen) insertCells(chars int) {
+	newCells := make([]Cell, chars)
+	cellStyle := CellStyle{Foreground: s.currentFG, Background: s.currentBG}
 	for i := range newCells {
-		newCells[i] = widget.TextGridCell{
+		newCells[i] = Cell{
 			Rune:  ' ',
 			Style: cellStyle,
 		}
 	}
 
-	row := &t.content.Rows[t.cursorRow]
-	row.Cells = append(row.Cells[:t.cursorCol], append(newCells, row.Cells[t.cursorCol:]...)...)
+	s.ensureRow(s.cursorRow)
+	s.splitWideChar(s.cursorRow, s.cursorCol)
+	row := &s.content.Rows[s.cursorRow]
+	row.Cells = append(row.Cells[:s.cursorCol], append(newCells, row.Cells[s.cursorCol:]...)...)
+	s.content.MarkRowDirty(s.cursorRow)
+	s.padRow(s.cursorRow) // characters pushed past the last column are lost
+	s.cropWideChar(s.cursorRow)
 }
 
-func escapeInsertLines(t *Terminal, msg string) {
+func escapeInsertLines(s *Screen, msg string) {
 	rows, _ := strconv.Atoi(msg)
 	if rows == 0 {
 		rows = 1
 	}
-	i := t.scrollBottom
-	for ; i > t.cursorRow-rows; i-- {
-		t.content.SetRow(i, t.content.Row(i-rows))
+	if s.cursorRow < s.scrollTop || s.cursorRow > s.scrollBottom {
+		return
+	}
+	i := s.scrollBottom
+	for ; i >= s.cursorRow+rows; i-- {
+		s.content.SetRow(i, s.content.Row(i-rows))
+		s.padRow(i)
 	}
-	for ; i >= t.cursorRow; i-- {
-		t.content.SetRow(i, widget.TextGridRow{})
+	for ; i >= s.cursorRow; i-- {
+		s.content.SetRow(i, s.blankRow())
 	}
 }
 
-func escapeMoveCursorUp(t *Terminal, msg string) {
+func escapeDeleteLines(s *Screen, msg string) {
 	rows, _ := strconv.Atoi(msg)
 	if rows == 0 {
 		rows = 1
 	}
-	t.moveCursor(t.cursorRow-rows, t.cursorCol)
+	if s.cursorRow < s.scrollTop || s.cursorRow > s.scrollBottom {
+		return
+	}
+	i := s.cursorRow
+	for ; i <= s.scrollBottom-rows; i++ {
+		s.content.SetRow(i, s.content.Row(i+rows))
+		s.padRow(i)
+	}
+	for ; i <= s.scrollBottom; i++ {
+		s.content.SetRow(i, s.blankRow())
+	}
+}
+
+// escapeInsertColumns handles DECIC, inserting blank columns at the cursor in each row of the scroll region.
+func escapeInsertColumns(s *Screen, msg string) {
+	cols, _ := strconv.Atoi(msg)
+	if cols == 0 {
+		cols = 1
+	}
+	if s.cursorRow < s.scrollTop || s.cursorRow > s.scrollBottom {
+		return
+	}
+	for row := s.scrollTop; row <= s.scrollBottom && row < len(s.content.Rows); row++ {
+		s.padRow(row)
+		s.splitWideChar(row, s.cursorCol)
+		cells := s.content.Rows[row].Cells
+		if right := s.cursorCol + cols; right < len(cells) {
+			copy(cells[right:], cells[s.cursorCol:])
+		}
+		s.eraseCells(row, s.cursorCol, s.cursorCol+cols)
+		s.cropWideChar(row)
+	}
 }
 
-func escapeMoveCursorDown(t *Terminal, msg string) {
+// escapeDeleteColumns handles DECDC, removing columns at the cursor in each row of the scroll region.
+func escapeDeleteColumns(s *Screen, msg string) {
+	cols, _ := strconv.Atoi(msg)
+	if cols == 0 {
+		cols = 1
+	}
+	if s.cursorRow < s.scrollTop || s.cursorRow > s.scrollBottom {
+		return
+	}
+	for row := s.scrollTop; row <= s.scrollBottom && row < len(s.content.Rows); row++ {
+		s.padRow(row)
+		s.splitWideChar(row, s.cursorCol)
+		s.splitWideChar(row, s.cursorCol+cols)
+		cells := s.content.Rows[row].Cells
+		moved := 0
+		if right := s.cursorCol + cols; right < len(cells) {
+			moved = copy(cells[s.cursorCol:], cells[right:])
+		}
+		s.eraseCells(row, s.cursorCol+moved, len(cells))
+	}
+}
+
+func escapeMoveCursorUp(s *Screen, msg string) {
+	rows, _ := strconv.Atoi(msg)
+	if rows == 0 {
+		rows = 1
+	}
+	s.moveCursorToCell(s.cursorRow-rows, s.cursorCol)
+}
+
+func escapeMoveCursorDown(s *Screen, msg string) {
 	rows, _ := strconv.Atoi(msg)
 	if rows == 0 {
 		rows = 1
 	}
-	t.moveCursor(t.cursorRow+rows, t.cursorCol)
+	s.moveCursorToCell(s.cursorRow+rows, s.cursorCol)
 }
 
-func escapeMoveCursorRight(t *Terminal, msg string) {
+func escapeMoveCursorRight(s *Screen, msg string) {
 	cols, _ := strconv.Atoi(msg)
 	if cols == 0 {
 		cols = 1
 	}
-	t.moveCursor(t.cursorRow, t.cursorCol+cols)
+	s.moveCursor(s.cursorRow, s.cursorCol+cols)
 }
 
-func escapeMoveCursorLeft(t *Terminal, msg string) {
+func escapeMoveCursorLeft(s *Screen, msg string) {
 	cols, _ := strconv.Atoi(msg)
 	if cols == 0 {
 		cols = 1
 	}
-	t.moveCursor(t.cursorRow, t.cursorCol-cols)
+	s.clearWrapPending()
+	s.moveCursor(s.cursorRow, s.cursorCol-cols)
 }
 
-func escapeMoveCursorRow(t *Terminal, msg string) {
+func escapeMoveCursorRow(s *Screen, msg string) {
 	row, _ := strconv.Atoi(msg)
-	t.moveCursor(row-1, t.cursorCol)
+	s.moveCursorToCell(s.originRow()+row-1, s.cursorCol)
 }
 
-func escapeMoveCursorCol(t *Terminal, msg string) {
+func escapeMoveCursorCol(s *Screen, msg string) {
 	col, _ := strconv.Atoi(msg)
-	t.moveCursor(t.cursorRow, col-1)
+	s.moveCursorToCell(s.cursorRow, col-1)
 }
 
-func escapePrivateMode(t *Terminal, msg string, enable bool) {
+func escapePrivateMode(s *Screen, msg string, enable bool) {
 	modes := strings.Split(msg, ";")
 	for _, mode := range modes {
+		s.sendEvent(TerminalEvent{Type: EventModeChanged, Mode: "?" + mode, Enabled: enable})
 		switch mode {
+		case "1":
+			s.applicationCursorKeys = enable
+		case "3":
+			s.setColumnMode(enable)
+		case "6":
+			s.originMode = enable
+			s.moveCursor(s.originRow(), 0)
 		case "7":
-			//TODO wrap around mode
+			s.autoWrap = enable
 		case "20":
-			t.newLineMode = enable
+			s.newLineMode = enable
 		case "25":
-			t.cursorHidden = !enable
-			t.refreshCursor()
+			s.cursorHidden = !enable
 		case "9":
+			s.setMouseTracking(mouseTrackingX10, enable)
+		case "1000":
+			s.setMouseTracking(mouseTrackingNormal, enable)
+		case "1005":
+			s.mouseUTF8 = enable
+		case "1006":
+			s.mouseSGR = enable
+		case "45":
+			s.reverseWrap = enable
+		case "67":
+			s.backarrowSendsBS = enable
+		case "47":
+			s.setAltScreen(enable)
+		case "1047":
+			if !enable && s.altScreen {
+				s.clearAltScreen()
+			}
+			s.setAltScreen(enable)
+		case "1048":
 			if enable {
-				t.onMouseDown = t.handleMouseDownX10
-				t.onMouseUp = t.handleMouseUpX10
+				s.saveCursor()
 			} else {
-				t.onMouseDown = nil
-				t.onMouseUp = nil
+				s.restoreCursor()
 			}
-		case "1000":
+		case "1049":
 			if enable {
-				t.onMouseDown = t.handleMouseDownV200
-				t.onMouseUp = t.handleMouseUpV200
+				s.saveCursor()
+				s.setAltScreen(true)
+				s.clearAltScreen()
 			} else {
-				t.onMouseDown = nil
-				t.onMouseUp = nil
+				s.setAltScreen(false)
+				s.restoreCursor()
 			}
-		case "1049":
-			t.bufferMode = enable
 		case "2004":
-			t.bracketedPasteMode = enable
-		case "47":
-			// TODO save screen
-			/*
-				if enable {
-					// save screen
-				} else {
-					// restore screen
-				}
-			*/
+			s.bracketedPasteMode = enable
 		default:
 			m := "l"
 			if enable {
 				m = "h"
 			}
-			if t.debug {
+			if s.debug {
 				log.Println("Unknown private escape code", fmt.Sprintf("%s%s", mode, m))
 			}
 		}
 	}
 }
 
-func escapePrivateModeOff(t *Terminal, msg string) {
-	escapePrivateMode(t, msg[1:], false)
+// setMouseTracking turns mouse reporting on in the given mode, or off if enable is false.
+func (s *Screen) setMouseTracking(mode mouseTracking, enable bool) {
+	if !enable {
+		mode = mouseTrackingOff
+	}
+	s.mouseTracking = mode
+}
+
+// setColumnMode handles DECCOLM, switching to 132 columns if wide is true or to 80 otherwise.
+// The screen is cleared and the scroll region reset, it is ignored unless allowed by SetAllowColumnModeSwitch.
+func (s *Screen) setColumnMode(wide bool) {
+	if !s.allowColumnSwitch {
+		return
+	}
+
+	cols := uint(80)
+	if wide {
+		cols = 132
+	}
+	s.resizeGrid(s.config.Rows, cols)
+	s.scrollTop, s.scrollBottom = 0, int(s.config.Rows)-1
+	s.clearScreen()
+}
+
+// escapeMode handles the ANSI modes set by SM (CSI h) and reset by RM (CSI l).
+func escapeMode(s *Screen, msg string, enable bool) {
+	for _, mode := range strings.Split(msg, ";") {
+		s.sendEvent(TerminalEvent{Type: EventModeChanged, Mode: mode, Enabled: enable})
+		switch mode {
+		case "4":
+			s.insertMode = enable
+		case "20":
+			s.newLineMode = enable
+		default:
+			if s.debug {
+				log.Println("Unknown mode", mode)
+			}
+		}
+	}
+}
+
+func escapePrivateModeOff(s *Screen, msg string) {
+	if !strings.HasPrefix(msg, "?") {
+		escapeMode(s, msg, false)
+		return
+	}
+	escapePrivateMode(s, msg[1:], false)
 }
 
-func escapePrivateModeOn(t *Terminal, msg string) {
-	escapePrivateMode(t, msg[1:], true)
+func escapePrivateModeOn(s *Screen, msg string) {
+	if !strings.HasPrefix(msg, "?") {
+		escapeMode(s, msg, true)
+		return
+	}
+	escapePrivateMode(s, msg[1:], true)
 }
 
-func escapeMoveCursor(t *Terminal, msg string) {
+func escapeMoveCursor(s *Screen, msg string) {
 	if !strings.Contains(msg, ";") {
-		t.moveCursor(0, 0)
+		s.moveCursor(s.originRow(), 0)
 		return
 	}
 
@@ -332,35 +618,60 @@ func escapeMoveCursor(t *Terminal, msg string) {
 		col, _ = strconv.Atoi(parts[1])
 	}
 
-	t.moveCursor(row-1, col-1)
+	s.moveCursorToCell(s.originRow()+row-1, col-1)
 }
 
-func escapeRestoreCursor(t *Terminal, _ string) {
-	t.moveCursor(t.savedRow, t.savedCol)
+// originRow returns the row that cursor addressing is relative to, taking origin mode into account.
+func (s *Screen) originRow() int {
+	if s.originMode {
+		return s.scrollTop
+	}
+	return 0
 }
 
-func escapeSaveCursor(t *Terminal, _ string) {
-	t.savedRow = t.cursorRow
-	t.savedCol = t.cursorCol
+func escapeRestoreCursor(s *Screen, _ string) {
+	s.moveCursor(s.scoSavedRow, s.scoSavedCol)
 }
 
-func escapeSetScrollArea(t *Terminal, msg string) {
+func escapeSaveCursor(s *Screen, _ string) {
+	s.scoSavedRow = s.cursorRow
+	s.scoSavedCol = s.cursorCol
+}
+
+// setScrollRegion sets the area that scrolls and moves the cursor home (DECSTBM), the state lock must be held.
+// A region that does not contain at least two rows is ignored.
+func (s *Screen) setScrollRegion(top, bottom int) {
+	if top < 0 {
+		top = 0
+	}
+	if last := int(s.config.Rows) - 1; last >= 0 && bottom > last {
+		bottom = last
+	}
+	if top >= bottom {
+		return
+	}
+
+	s.scrollTop = top
+	s.scrollBottom = bottom
+	s.moveCursor(s.originRow(), 0)
+}
+
+func escapeSetScrollArea(s *Screen, msg string) {
 	parts := strings.Split(msg, ";")
 	start := 0
-	end := int(t.config.Rows) - 1
-	if len(parts) == 2 {
-		if parts[0] != "" {
-			start, _ = strconv.Atoi(parts[0])
-			start--
+	end := int(s.config.Rows) - 1
+	if parts[0] != "" {
+		if i, _ := strconv.Atoi(parts[0]); i > 0 {
+			start = i - 1
 		}
-		if parts[1] != "" {
-			end, _ = strconv.Atoi(parts[1])
-			end--
+	}
+	if len(parts) >= 2 && parts[1] != "" {
+		if i, _ := strconv.Atoi(parts[1]); i > 0 {
+			end = i - 1
 		}
 	}
 
-	t.scrollTop = start
-	t.scrollBottom = end
+	s.setScrollRegion(start, end)
 }
 
 func trimLeftZeros(s string) string {
@@ -370,7 +681,7 @@ func trimLeftZeros(s string) string {
 
 	i := 0
 	for _, r := range s {
-		if r > '0' {
+		if r > '0' || isIntermediate(r) {
 			break
 		}
 		i++
@@ -379,24 +690,57 @@ func trimLeftZeros(s string) string {
 	return s[i:]
 }
 
-func escapePrinterMode(t *Terminal, code string) {
+// escapeDeviceAttributes replies to a primary device attributes request (DA1). It reports a VT220 with
+// selective erase and ANSI colour, to match the xterm-256color TERM given to the shell, and 132 columns
+// only when switching to them is allowed.
+func escapeDeviceAttributes(s *Screen, msg string) {
+	if msg != "" {
+		return // secondary or tertiary attributes, which we do not report
+	}
+	if s.allowColumnSwitch {
+		s.reply([]byte("\x1b[?62;1;6;22c"))
+		return
+	}
+	s.reply([]byte("\x1b[?62;6;22c"))
+}
+
+// escapeWindowOps handles the window operations (XTWINOPS) that make sense inside a widget, which is
+// reporting the title, and setting the title modes (XTSMTITLE) when the parameters start with ">".
+func escapeWindowOps(s *Screen, msg string) {
+	if strings.HasPrefix(msg, ">") {
+		s.setTitleModes(msg[1:], true)
+		return
+	}
+	if msg == "21" {
+		s.reportTitle()
+	}
+}
+
+// escapeResetTitleModes turns off title modes (XTRMTITLE), other uses of this final character are not supported.
+func escapeResetTitleModes(s *Screen, msg string) {
+	if strings.HasPrefix(msg, ">") {
+		s.setTitleModes(msg[1:], false)
+	}
+}
+
+func escapePrinterMode(s *Screen, code string) {
 	switch code {
 	case "5":
-		t.state.printing = true
+		s.state.printing = true
 	case "4":
-		t.state.printing = false
-		if t.printData != nil {
-			if t.printer != nil {
+		s.state.printing = false
+		if s.printData != nil {
+			if s.printer != nil {
 				// spool the printer
-				t.printer.Print(t.printData)
-			} else if t.debug {
+				s.printer.Print(s.printData)
+			} else if s.debug {
 				log.Println("Print data was received but no printer has been set")
 			}
 
 		}
-		t.printData = nil
+		s.printData = nil
 	default:
-		if t.debug {
+		if s.debug {
 			log.Println("Unknown printer mode", code)
 		}
 	}
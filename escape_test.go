@@ -1,6 +1,8 @@
 package terminal
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"fyne.io/fyne/v2"
@@ -9,93 +11,148 @@ import (
 
 func TestClearScreen(t *testing.T) {
 	term := New()
-	term.config.Columns = 5
-	term.config.Rows = 2
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
 	term.handleOutput([]byte("Hello"))
-	assert.Equal(t, "Hello", term.content.Text())
+	assert.Equal(t, "Hello", term.screen.content.Text())
 
-	term.handleEscape("2J")
-	assert.Equal(t, "", term.content.Text())
+	term.screen.handleEscape("2J")
+	assert.Equal(t, "", term.screen.content.Text())
 }
 
 func TestInsertDeleteChars(t *testing.T) {
 	term := New()
-	term.config.Columns = 5
-	term.config.Rows = 2
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
 	term.handleOutput([]byte("Hello"))
-	assert.Equal(t, "Hello", term.content.Text())
+	assert.Equal(t, "Hello", term.screen.content.Text())
 
-	term.moveCursor(0, 2)
-	term.handleEscape("2@")
-	assert.Equal(t, "He  llo", term.content.Text())
-	term.handleEscape("3P")
-	assert.Equal(t, "Helo", term.content.Text())
+	term.screen.moveCursor(0, 2)
+	term.screen.handleEscape("2@")
+	assert.Equal(t, "He  l", term.screen.content.Text()) // characters pushed past the last column are lost
+	term.screen.handleEscape("3P")
+	assert.Equal(t, "He", term.screen.content.Text())
+}
+
+func TestInsertDeleteLines(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 3
+	term.screen.config.Rows = 4
+	term.screen.scrollBottom = 3
+	term.handleOutput([]byte("a\r\nb\r\nc"))
+
+	term.screen.moveCursor(1, 0)
+	term.screen.handleEscape("L")
+	assert.Equal(t, "a\n\nb\nc", term.screen.content.Text())
+
+	term.screen.handleEscape("M")
+	assert.Equal(t, "a\nb\nc\n", term.screen.content.Text())
 }
 
 func TestEraseLine(t *testing.T) {
 	term := New()
-	term.config.Columns = 5
-	term.config.Rows = 2
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte("Hello"))
+	assert.Equal(t, "Hello", term.screen.content.Text())
+
+	term.screen.moveCursor(0, 2)
+	term.screen.handleEscape("K")
+	assert.Equal(t, "He", term.screen.content.Text())
+}
+
+func TestEraseLine_Modes(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
 	term.handleOutput([]byte("Hello"))
-	assert.Equal(t, "Hello", term.content.Text())
 
-	term.moveCursor(0, 2)
-	term.handleEscape("K")
-	assert.Equal(t, "He", term.content.Text())
+	term.screen.moveCursor(0, 2)
+	term.screen.handleEscape("1K")
+	assert.Equal(t, "  llo", term.screen.content.Text())
+	term.screen.handleEscape("2K")
+	assert.Equal(t, "", term.screen.content.Text())
+
+	term.handleOutput([]byte("\r\nab"))
+	term.screen.handleEscape("1J")
+	assert.Equal(t, "\n", term.screen.content.Text())
 }
 
 func TestCursorMove(t *testing.T) {
 	term := New()
-	term.config.Columns = 5
-	term.config.Rows = 2
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
 	term.handleOutput([]byte("Hello"))
-	assert.Equal(t, 0, term.cursorRow)
-	assert.Equal(t, 5, term.cursorCol)
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 5, term.screen.cursorCol)
 
-	term.handleEscape("1;4H")
-	assert.Equal(t, 0, term.cursorRow)
-	assert.Equal(t, 3, term.cursorCol)
+	term.screen.handleEscape("1;4H")
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 3, term.screen.cursorCol)
 
-	term.handleEscape("2D")
-	assert.Equal(t, 0, term.cursorRow)
-	assert.Equal(t, 1, term.cursorCol)
+	term.screen.handleEscape("2D")
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
 
-	term.handleEscape("2C")
-	assert.Equal(t, 0, term.cursorRow)
-	assert.Equal(t, 3, term.cursorCol)
+	term.screen.handleEscape("2C")
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 3, term.screen.cursorCol)
 
-	term.handleEscape("1B")
-	assert.Equal(t, 1, term.cursorRow)
-	assert.Equal(t, 3, term.cursorCol)
+	term.screen.handleEscape("1B")
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 3, term.screen.cursorCol)
 
-	term.handleEscape("1A")
-	assert.Equal(t, 0, term.cursorRow)
-	assert.Equal(t, 3, term.cursorCol)
+	term.screen.handleEscape("1A")
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 3, term.screen.cursorCol)
 }
 
 func TestCursorMove_Overflow(t *testing.T) {
 	term := New()
-	term.config.Columns = 2
-	term.config.Rows = 2
-	term.handleEscape("2;2H")
-	assert.Equal(t, 1, term.cursorRow)
-	assert.Equal(t, 1, term.cursorCol)
+	term.screen.config.Columns = 2
+	term.screen.config.Rows = 2
+	term.screen.handleEscape("2;2H")
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
+
+	term.screen.handleEscape("2D")
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 0, term.screen.cursorCol)
+
+	term.screen.handleEscape("5C")
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
+
+	term.screen.handleEscape("5A")
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
+
+	term.screen.handleEscape("4B")
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
+}
+
+func TestCursorMove_OriginMode(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 10
+	term.handleOutput([]byte(esc("[3;6r") + esc("[?6h")))
+	assert.Equal(t, 2, term.screen.cursorRow)
+	assert.Equal(t, 0, term.screen.cursorCol)
 
-	term.handleEscape("2D")
-	assert.Equal(t, 1, term.cursorRow)
-	assert.Equal(t, 0, term.cursorCol)
+	term.handleOutput([]byte(esc("[99;1H")))
+	assert.Equal(t, 5, term.screen.cursorRow)
 
-	term.handleEscape("5C")
-	assert.Equal(t, 1, term.cursorRow)
-	assert.Equal(t, 1, term.cursorCol)
+	term.handleOutput([]byte(esc("[2;3H")))
+	assert.Equal(t, 3, term.screen.cursorRow)
+	assert.Equal(t, 2, term.screen.cursorCol)
 
-	term.handleEscape("5A")
-	assert.Equal(t, 0, term.cursorRow)
-	assert.Equal(t, 1, term.cursorCol)
+	term.handleOutput([]byte(esc("[9A")))
+	assert.Equal(t, 2, term.screen.cursorRow)
 
-	term.handleEscape("4B")
-	assert.Equal(t, 1, term.cursorRow)
-	assert.Equal(t, 1, term.cursorCol)
+	term.handleOutput([]byte(esc("[?6l") + esc("[99;1H")))
+	assert.Equal(t, 9, term.screen.cursorRow)
 }
 
 func TestTrimLeftZeros(t *testing.T) {
@@ -184,11 +241,11 @@ func TestHandleOutput_NewLineMode(t *testing.T) {
 
 			term.handleOutput([]byte(tt.input))
 
-			assert.Equal(t, tt.expectedCursorRow, term.cursorRow)
-			assert.Equal(t, tt.expectedCursorCol, term.cursorCol)
-			assert.Equal(t, tt.expectedNewLineMode, term.newLineMode)
-			assert.Equal(t, tt.expectedContentText, term.content.Text())
-			assert.Equal(t, tt.expectedContentRowCount, len(term.content.Rows))
+			assert.Equal(t, tt.expectedCursorRow, term.screen.cursorRow)
+			assert.Equal(t, tt.expectedCursorCol, term.screen.cursorCol)
+			assert.Equal(t, tt.expectedNewLineMode, term.screen.newLineMode)
+			assert.Equal(t, tt.expectedContentText, term.screen.content.Text())
+			assert.Equal(t, tt.expectedContentRowCount, len(term.screen.content.Rows))
 		})
 	}
 }
@@ -224,18 +281,301 @@ func TestTerminalEscapeSequences(t *testing.T) {
 			expected:    "⎺⎺⎺⎺o",
 			description: "Test set G1 to DEC charset and 'SO' to switch to G1, then 'SI' to G0",
 		},
+		{
+			input:       string([]byte{asciiEscape}) + "(A#5",
+			expected:    "£5",
+			description: "Test set G0 to UK charset",
+		},
+		{
+			input:       string([]byte{asciiEscape}) + "(K{|}~" + string([]byte{asciiEscape}) + "(B~",
+			expected:    "äöüß~",
+			description: "Test set G0 to German charset and back to ASCII",
+		},
+		{
+			input:       string([]byte{asciiEscape, ')', 'R', 0x0e}) + "@\\",
+			expected:    "àç",
+			description: "Test set G1 to French charset and 'SO' to switch to G1",
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.description, func(t *testing.T) {
 			term := New()
-			term.config.Columns = 10
-			term.config.Rows = 1
+			term.screen.config.Columns = 10
+			term.screen.config.Rows = 1
 			term.handleOutput([]byte(testCase.input))
-			actual := term.content.Text()
+			actual := term.screen.content.Text()
 			if actual != testCase.expected {
 				t.Errorf("Expected: %s, Got: %s", testCase.expected, actual)
 			}
 		})
 	}
 }
+
+func TestRegisterCSIHandler(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	var got []string
+	term.RegisterCSIHandler('z', "", func(_ *Terminal, params string) {
+		got = append(got, "z:"+params)
+	})
+	term.RegisterCSIHandler('q', " ", func(_ *Terminal, params string) {
+		got = append(got, "q:"+params)
+	})
+
+	term.handleOutput([]byte(esc("[12;3z") + esc("[4 q") + esc("[5q")))
+	assert.Equal(t, []string{"z:12;3", "q:4"}, got)
+	assert.Equal(t, CursorShapeCaret, term.screen.cursorShape) // built in handler was overridden
+}
+
+func BenchmarkEraseInLine(b *testing.B) {
+	term := New()
+	term.screen.config.Columns = 80
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte(strings.Repeat("x", 80)))
+	term.screen.moveCursor(0, 40)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		escapeEraseInLine(term.screen, "1")
+		escapeEraseInLine(term.screen, "2")
+	}
+}
+
+func TestAltScreen(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("a\r\nb\r\nc"))
+	assert.Equal(t, "b\nc", term.screen.content.Text())
+	assert.Equal(t, "a\nb\nc", term.FullText())
+	assert.False(t, term.OnAltScreen())
+
+	term.handleOutput([]byte(esc("[?1049h")))
+	assert.True(t, term.OnAltScreen())
+	assert.Equal(t, "", term.screen.content.Text())
+	term.handleOutput([]byte(esc("[H") + "vi\r\n\r\n\r\nmore"))
+	assert.Equal(t, 1, len(term.screen.scrollback)) // nothing added while on the alternate screen
+	assert.Equal(t, "b\nc", term.MainScreenText())
+
+	term.handleOutput([]byte(esc("[?1049l")))
+	assert.False(t, term.OnAltScreen())
+	assert.Equal(t, "b\nc", term.screen.content.Text())
+	assert.Equal(t, "b\nc", term.MainScreenText())
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
+}
+
+func TestAltScreen_Modes(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("main"))
+
+	term.handleOutput([]byte(esc("[?47h") + "\ralt"))
+	assert.Equal(t, "alt", term.screen.content.Text())
+	term.handleOutput([]byte(esc("[?47l")))
+	assert.Equal(t, "main", term.screen.content.Text())
+	assert.Equal(t, 3, term.screen.cursorCol) // 47 does not restore the cursor
+	term.handleOutput([]byte(esc("[?47h")))
+	assert.Equal(t, "alt", term.screen.content.Text()) // 47 does not clear
+
+	term.handleOutput([]byte(esc("[?47l") + esc("[?1047h")))
+	assert.Equal(t, "alt", term.screen.content.Text())
+	term.handleOutput([]byte(esc("[?1047l")))
+	assert.Equal(t, "main", term.screen.content.Text())
+	term.handleOutput([]byte(esc("[?47h")))
+	assert.Equal(t, "", term.screen.content.Text()) // 1047 cleared on exit
+
+	term.handleOutput([]byte(esc("[?47l") + esc("[1;2H") + esc("[?1048h") + esc("[2;4H") + esc("[?1048l")))
+	assert.False(t, term.OnAltScreen())
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
+
+	term.handleOutput([]byte(esc("[?47h") + "x" + esc("[?47l") + esc("[?1049h")))
+	assert.Equal(t, "", term.screen.content.Text()) // 1049 clears on entry
+	term.handleOutput([]byte("vi" + esc("[?1049l")))
+	assert.Equal(t, "main", term.screen.content.Text())
+	assert.Equal(t, 2, term.screen.cursorCol) // where "x" left it when 1049 saved the cursor
+}
+
+func TestSelectiveErase(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 6
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("ab" + esc("[1\"q") + "cd" + esc("[0\"q") + "ef\r\nghij"))
+
+	term.handleOutput([]byte(esc("[?2K")))
+	assert.Equal(t, "abcdef\n", term.screen.content.Text())
+	term.handleOutput([]byte(esc("[1;1H") + esc("[?0K")))
+	assert.Equal(t, "  cd\n", term.screen.content.Text())
+
+	term.handleOutput([]byte(esc("[2K")))
+	assert.Equal(t, "\n", term.screen.content.Text()) // normal erase clears protected cells
+}
+
+func TestSelectiveErase_ToCursor(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 6
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("ab" + esc("[1\"q") + "cd" + esc("[0\"q") + "ef\r\nghij"))
+
+	term.handleOutput([]byte(esc("[1;5H") + esc("[?1K")))
+	assert.Equal(t, "  cd f\nghij", term.screen.content.Text()) // the cell under the cursor is erased
+
+	term.handleOutput([]byte(esc("[2;2H") + esc("[?1J")))
+	assert.Equal(t, "  cd\n  ij", term.screen.content.Text())
+}
+
+func TestInsertDeleteColumns(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 3
+	term.screen.scrollBottom = 2
+	term.handleOutput([]byte("abcde\r\nfghij\r\nklmno" + esc("[1;2r") + esc("[1;2H")))
+
+	term.handleOutput([]byte(esc("[2'}")))
+	assert.Equal(t, "a  bc\nf  gh\nklmno", term.screen.content.Text())
+
+	term.handleOutput([]byte(esc("[3'~")))
+	assert.Equal(t, "ac\nfh\nklmno", term.screen.content.Text())
+}
+
+func TestColumnMode(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 40
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("text" + esc("[?3h")))
+	assert.Equal(t, uint(40), term.screen.config.Columns) // not allowed by default
+	assert.Equal(t, "text", term.screen.content.Text())
+
+	term.SetAllowColumnModeSwitch(true)
+	term.handleOutput([]byte(esc("[?3h")))
+	assert.Equal(t, uint(132), term.screen.config.Columns)
+	assert.Equal(t, "", strings.TrimSpace(term.screen.content.Text()))
+	assert.Equal(t, 0, term.screen.cursorCol)
+
+	term.handleOutput([]byte(esc("[?3l")))
+	assert.Equal(t, uint(80), term.screen.config.Columns)
+}
+
+func TestSaveCursor_SeparateAreas(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+
+	term.handleOutput([]byte(esc("[2;3H") + "\x1b7" + esc("[4;5H") + esc("[s")))
+	term.handleOutput([]byte(esc("[1;1H") + "\x1b8"))
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 2, term.screen.cursorCol)
+
+	term.handleOutput([]byte(esc("[u")))
+	assert.Equal(t, 3, term.screen.cursorRow)
+	assert.Equal(t, 4, term.screen.cursorCol)
+
+	term.handleOutput([]byte(esc("[5;1H") + esc("[s") + "\x1b8"))
+	assert.Equal(t, 1, term.screen.cursorRow) // CSI s did not replace the DECSC position
+	assert.Equal(t, 2, term.screen.cursorCol)
+}
+
+func TestInsertMode(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 6
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("abcdef" + esc("[1;3H") + esc("[4h") + "XY"))
+	assert.Equal(t, "abXYcd", term.screen.content.Text()) // the end of the line is pushed off
+	assert.Equal(t, 4, term.screen.cursorCol)
+
+	term.handleOutput([]byte(esc("[4l") + "Z"))
+	assert.Equal(t, "abXYZd", term.screen.content.Text())
+}
+
+func TestScrollRegion_LineFeedOutsideMargins(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 3
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+	term.handleOutput([]byte("1\r\n2\r\n3\r\n4\r\n5" + esc("[2;4r")))
+
+	term.handleOutput([]byte(esc("[1;1H") + esc("M")))
+	assert.Equal(t, 0, term.screen.cursorRow)
+	term.handleOutput([]byte("\n"))
+	assert.Equal(t, 1, term.screen.cursorRow)
+	term.handleOutput([]byte(esc("[5;1H") + "\n" + esc("D")))
+	assert.Equal(t, 4, term.screen.cursorRow)
+	assert.Equal(t, "1\n2\n3\n4\n5", term.screen.content.Text()) // nothing scrolled
+
+	term.handleOutput([]byte(esc("[4;1H") + "\n"))
+	assert.Equal(t, 3, term.screen.cursorRow)
+	assert.Equal(t, "1\n3\n4\n\n5", term.screen.content.Text())
+	term.handleOutput([]byte(esc("[2;1H") + esc("M")))
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, "1\n\n3\n4\n5", term.screen.content.Text())
+}
+
+func TestTerminal_SetScrollRegion(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 3
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+	term.handleOutput([]byte("1\r\n2\r\n3\r\n4\r\n5"))
+
+	term.SetScrollRegion(1, 3)
+	top, bottom := term.ScrollRegion()
+	assert.Equal(t, 1, top)
+	assert.Equal(t, 3, bottom)
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 0, term.screen.cursorCol)
+
+	term.handleOutput([]byte(esc("[4;1H") + "\n"))
+	assert.Equal(t, "1\n3\n4\n\n5", term.screen.content.Text())
+
+	term.SetScrollRegion(3, 3) // too small, ignored
+	top, bottom = term.ScrollRegion()
+	assert.Equal(t, 1, top)
+	assert.Equal(t, 3, bottom)
+
+	term.SetScrollRegion(-1, 99)
+	top, bottom = term.ScrollRegion()
+	assert.Equal(t, 0, top)
+	assert.Equal(t, 4, bottom)
+
+	term.handleOutput([]byte(esc("[2;3r")))
+	top, bottom = term.ScrollRegion()
+	assert.Equal(t, 1, top)
+	assert.Equal(t, 2, bottom)
+	term.handleOutput([]byte(esc("[4;2r"))) // top below bottom, ignored
+	top, bottom = term.ScrollRegion()
+	assert.Equal(t, 1, top)
+	assert.Equal(t, 2, bottom)
+	term.handleOutput([]byte(esc("[r")))
+	top, bottom = term.ScrollRegion()
+	assert.Equal(t, 0, top)
+	assert.Equal(t, 4, bottom)
+}
+
+func TestDeviceAttributes(t *testing.T) {
+	term := New()
+	buf := &bytes.Buffer{}
+	term.in = NopCloser(buf)
+
+	term.handleOutput([]byte(esc("[c") + esc("[>c")))
+	assert.Equal(t, "\x1b[?62;6;22c", buf.String())
+	buf.Reset()
+	term.handleOutput([]byte(esc("[0c")))
+	assert.Equal(t, "\x1b[?62;6;22c", buf.String())
+
+	buf.Reset()
+	term.SetAllowColumnModeSwitch(true)
+	term.handleOutput([]byte(esc("[c")))
+	assert.Equal(t, "\x1b[?62;1;6;22c", buf.String()) // 132 columns only when they can be switched to
+}
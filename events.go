@@ -0,0 +1,76 @@
+package terminal
+
+// EventType identifies the kind of a TerminalEvent.
+type EventType int
+
+const (
+	// EventTitle is sent when the title is changed, Title holds the new title.
+	EventTitle EventType = iota
+	// EventBell is sent when the bell rings.
+	EventBell
+	// EventCursorMoved is sent when the cursor is positioned, Row and Col hold the new position.
+	EventCursorMoved
+	// EventModeChanged is sent when a mode is set or reset, Mode holds its number with a "?" prefix
+	// for DEC private modes and Enabled holds the new state.
+	EventModeChanged
+	// EventResize is sent when the grid size changes, Rows and Cols hold the new size.
+	EventResize
+)
+
+// TerminalEvent describes something that happened in the terminal, the fields that are set depend on the Type.
+type TerminalEvent struct {
+	Type EventType
+
+	Title      string
+	Row, Col   int
+	Mode       string
+	Enabled    bool
+	Rows, Cols uint
+}
+
+// AddEventListener registers a channel that will receive the events parsed from the terminal output.
+// Events are dropped if the channel is not ready to receive them, so it should be buffered.
+func (s *Screen) AddEventListener(listener chan TerminalEvent) {
+	s.listenerLock.Lock()
+	defer s.listenerLock.Unlock()
+
+	s.eventListeners = append(s.eventListeners, listener)
+}
+
+// AddEventListener registers a channel that will receive the events parsed from the terminal output.
+// Events are dropped if the channel is not ready to receive them, so it should be buffered.
+func (t *Terminal) AddEventListener(listener chan TerminalEvent) {
+	t.screen.AddEventListener(listener)
+}
+
+// RemoveEventListener stops sending events to a channel added with AddEventListener and closes it.
+func (s *Screen) RemoveEventListener(listener chan TerminalEvent) {
+	s.listenerLock.Lock()
+	defer s.listenerLock.Unlock()
+
+	for i, l := range s.eventListeners {
+		if l == listener {
+			s.eventListeners = append(s.eventListeners[:i], s.eventListeners[i+1:]...)
+			close(l)
+			return
+		}
+	}
+}
+
+// RemoveEventListener stops sending events to a channel added with AddEventListener and closes it.
+func (t *Terminal) RemoveEventListener(listener chan TerminalEvent) {
+	t.screen.RemoveEventListener(listener)
+}
+
+func (s *Screen) sendEvent(e TerminalEvent) {
+	s.listenerLock.Lock()
+	defer s.listenerLock.Unlock()
+
+	for _, l := range s.eventListeners {
+		select {
+		case l <- e:
+		default:
+			// channel blocked, might be closed
+		}
+	}
+}
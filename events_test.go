@@ -0,0 +1,27 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventListener(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 5
+	events := make(chan TerminalEvent, 10)
+	term.AddEventListener(events)
+
+	term.handleOutput([]byte(esc("]2;Title\a") + esc("[3;4H") + esc("[?25l") + esc("[4h")))
+	assert.Equal(t, TerminalEvent{Type: EventTitle, Title: "Title"}, <-events)
+	assert.Equal(t, TerminalEvent{Type: EventCursorMoved, Row: 2, Col: 3}, <-events)
+	assert.Equal(t, TerminalEvent{Type: EventModeChanged, Mode: "?25", Enabled: false}, <-events)
+	assert.Equal(t, TerminalEvent{Type: EventModeChanged, Mode: "4", Enabled: true}, <-events)
+	assert.Equal(t, 0, len(events))
+
+	term.RemoveEventListener(events)
+	term.handleOutput([]byte(esc("]2;Other\a")))
+	_, open := <-events
+	assert.False(t, open)
+}
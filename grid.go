@@ -0,0 +1,149 @@
+package terminal
+
+// wideCharPadding is stored in the cell after a double width character.
+// It is not shown, as the wide character covers it, and is skipped when extracting text.
+const wideCharPadding rune = -1
+
+// Cell is a character on the screen with the attributes it was printed with.
+type Cell struct {
+	Rune  rune
+	Style CellStyle
+}
+
+// gridRow is a line of cells on the screen or in the scrollback.
+type gridRow struct {
+	Cells []Cell
+}
+
+// grid holds the rows of the screen, recording which have changed so that a view of it
+// only needs to update those rows. It is guarded by the state lock of its Screen.
+type grid struct {
+	Rows []gridRow
+
+	dirtyRows map[int]bool
+}
+
+// Row returns the row at the given index, or an empty row if it does not exist.
+func (g *grid) Row(row int) gridRow {
+	if row < 0 || row >= len(g.Rows) {
+		return gridRow{}
+	}
+
+	return g.Rows[row]
+}
+
+// SetRow replaces the row at the given index, adding empty rows above it if needed, and marks it changed.
+func (g *grid) SetRow(row int, content gridRow) {
+	if row < 0 {
+		return
+	}
+	for len(g.Rows) <= row {
+		g.Rows = append(g.Rows, gridRow{})
+	}
+
+	g.Rows[row] = content
+	g.MarkRowDirty(row)
+}
+
+// SetCell replaces the cell at the given row and column, growing the grid if needed, and marks the row changed.
+func (g *grid) SetCell(row, col int, cell Cell) {
+	if row < 0 || col < 0 {
+		return
+	}
+	for len(g.Rows) <= row {
+		g.Rows = append(g.Rows, gridRow{})
+	}
+	for len(g.Rows[row].Cells) <= col {
+		g.Rows[row].Cells = append(g.Rows[row].Cells, Cell{})
+	}
+
+	g.Rows[row].Cells[col] = cell
+	g.MarkRowDirty(row)
+}
+
+// MarkRowDirty records that a row has changed. Code that modifies Rows directly must call this.
+func (g *grid) MarkRowDirty(row int) {
+	g.MarkRowsDirty(row, row)
+}
+
+// MarkRowsDirty records that the rows from startRow to endRow (inclusive) have changed.
+func (g *grid) MarkRowsDirty(startRow, endRow int) {
+	if startRow > endRow {
+		startRow, endRow = endRow, startRow
+	}
+	if g.dirtyRows == nil {
+		g.dirtyRows = make(map[int]bool)
+	}
+	for i := startRow; i <= endRow; i++ {
+		g.dirtyRows[i] = true
+	}
+}
+
+func (g *grid) takeDirtyRows() map[int]bool {
+	rows := g.dirtyRows
+	g.dirtyRows = nil
+	return rows
+}
+
+// Text returns the contents of the grid as a single string joined with `\n`, without style information.
+// Blank cells at the end of each row are not included.
+func (g *grid) Text() string {
+	return g.TextRange(0, len(g.Rows)-1)
+}
+
+// TextRange returns the contents of the rows from startRow to endRow (inclusive) joined with `\n`.
+// Row numbers outside the grid are clamped.
+func (g *grid) TextRange(startRow, endRow int) string {
+	if startRow < 0 {
+		startRow = 0
+	}
+	if endRow >= len(g.Rows) {
+		endRow = len(g.Rows) - 1
+	}
+	if endRow < startRow {
+		return ""
+	}
+
+	return rowsText(g.Rows[startRow : endRow+1])
+}
+
+// rowsText returns the contents of the rows joined with `\n`, without style information.
+// Blank cells at the end of each row are not included.
+func rowsText(rows []gridRow) string {
+	var runes []rune
+	for i, row := range rows {
+		cells := row.Cells
+		end := len(cells)
+		for end > 0 && cells[end-1].isBlank() {
+			end--
+		}
+		for _, cell := range cells[:end] {
+			if cell.Rune == wideCharPadding {
+				continue
+			}
+			if cell.Rune == 0 {
+				cell.Rune = ' '
+			}
+			runes = append(runes, cell.Rune)
+		}
+		if i < len(rows)-1 {
+			runes = append(runes, '\n')
+		}
+	}
+
+	return string(runes)
+}
+
+// reported returns the cell as it is returned by Line and Cell,
+// where the second half of a double width character is a zero Cell.
+func (c Cell) reported() Cell {
+	if c.Rune == wideCharPadding {
+		return Cell{}
+	}
+	return c
+}
+
+// isBlank returns true if the cell shows nothing, being an unset rune or a space without a background colour.
+func (c Cell) isBlank() bool {
+	return (c.Rune == ' ' || c.Rune == 0) && c.Style.Background == nil
+}
@@ -0,0 +1,213 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"  // register the GIF decoder for inline images
+	_ "image/jpeg" // register the JPEG decoder for inline images
+	_ "image/png"  // register the PNG decoder for inline images
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxImageDataLength = 32 * 1024 * 1024 // the largest encoded image accepted, in bytes
+	maxImageDimension  = 10000            // the largest width or height of an image accepted, in pixels
+)
+
+// inlineImage is a picture placed in the terminal content, anchored to the cell at its top left.
+type inlineImage struct {
+	img           image.Image
+	row, col      int
+	width, height int    // in cells
+	id            uint32 // the kitty graphics image id, if any
+	z             int    // images with a negative z-index are drawn below the text
+}
+
+// SetITerm2CommandHandler sets a function to call with any iTerm2 (OSC 1337) command other than File=,
+// such as "SetUserVar=name=dmFsdWU=". Pass nil to ignore these commands.
+func (s *Screen) SetITerm2CommandHandler(handler func(string)) {
+	s.iTerm2Handler = handler
+}
+
+// SetITerm2CommandHandler sets a function to call with any iTerm2 (OSC 1337) command other than File=,
+// such as "SetUserVar=name=dmFsdWU=". Pass nil to ignore these commands.
+func (t *Terminal) SetITerm2CommandHandler(handler func(string)) {
+	t.screen.SetITerm2CommandHandler(handler)
+}
+
+// handleITerm2 processes the payload of an OSC 1337 sequence.
+func (s *Screen) handleITerm2(command string) {
+	if !strings.HasPrefix(command, "File=") {
+		if s.iTerm2Handler != nil {
+			s.iTerm2Handler(command)
+		}
+		return
+	}
+
+	parts := strings.SplitN(command[5:], ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+	args := make(map[string]string)
+	for _, arg := range strings.Split(parts[0], ";") {
+		if pair := strings.SplitN(arg, "=", 2); len(pair) == 2 {
+			args[pair[0]] = pair[1]
+		}
+	}
+	if args["inline"] != "1" {
+		return // downloading files is not supported
+	}
+
+	if len(parts[1]) > maxImageDataLength {
+		if s.debug {
+			log.Println("Inline image data is too long", len(parts[1]))
+		}
+		return
+	}
+	raw, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		if s.debug {
+			log.Println("Failed to decode inline image data", err)
+		}
+		return
+	}
+	img, err := decodeImage(raw)
+	if err != nil {
+		if s.debug {
+			log.Println("Failed to decode inline image", err)
+		}
+		return
+	}
+
+	s.placeImage(img, args["width"], args["height"], args["preserveAspectRatio"] != "0", true)
+}
+
+// decodeImage decodes a GIF, JPEG or PNG image, checking its size before the pixels are decoded
+// so that a small file cannot claim a huge image.
+func decodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxImageDimension || config.Height > maxImageDimension {
+		return nil, fmt.Errorf("image size %dx%d is too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// placeImage adds an image at the cursor, sized using iTerm2 style dimensions,
+// and optionally moves the cursor to the end of the image.
+func (s *Screen) placeImage(img image.Image, width, height string, preserveAspect, moveCursor bool) *inlineImage {
+	cellWidth, cellHeight := float32(defaultCellWidth), float32(defaultCellHeight)
+	if s.cellSize != nil {
+		cellWidth, cellHeight = s.cellSize()
+	}
+	if cellWidth <= 0 || cellHeight <= 0 || s.config.Columns == 0 {
+		return nil
+	}
+
+	natural := img.Bounds().Size()
+	w := imageDimension(width, float32(natural.X), cellWidth, cellWidth*float32(s.config.Columns))
+	h := imageDimension(height, float32(natural.Y), cellHeight, cellHeight*float32(s.config.Rows))
+	if preserveAspect && natural.X > 0 && natural.Y > 0 {
+		ratio := float32(natural.X) / float32(natural.Y)
+		switch {
+		case isAuto(width) && !isAuto(height):
+			w = h * ratio
+		case isAuto(height) && !isAuto(width):
+			h = w / ratio
+		case w/h > ratio:
+			w = h * ratio
+		default:
+			h = w / ratio
+		}
+	}
+
+	if room := cellWidth * float32(int(s.config.Columns)-s.cursorCol); w > room {
+		if preserveAspect {
+			h = h * room / w
+		}
+		w = room
+	}
+	cols := int(math.Ceil(float64(w / cellWidth)))
+	rows := int(math.Ceil(float64(h / cellHeight)))
+	if cols < 1 || rows < 1 {
+		return nil
+	}
+
+	s.ensureRow(s.cursorRow)
+	col := s.cursorCol
+	placed := &inlineImage{img: img, row: s.cursorRow, col: col, width: cols, height: rows}
+	s.images = append(s.images, placed)
+	if !moveCursor {
+		return placed
+	}
+	for i := 1; i < rows; i++ {
+		handleOutputLineFeed(s) // the image moves with the content if this scrolls
+	}
+	s.moveCursor(s.cursorRow, col+cols)
+	return placed
+}
+
+// imageDimension converts an iTerm2 size argument, which is a number of cells, "Npx" or "N%", into pixels.
+func imageDimension(spec string, natural, cell, full float32) float32 {
+	switch {
+	case isAuto(spec):
+		return natural
+	case strings.HasSuffix(spec, "px"):
+		if n, err := strconv.ParseFloat(spec[:len(spec)-2], 32); err == nil {
+			return float32(n)
+		}
+	case strings.HasSuffix(spec, "%"):
+		if n, err := strconv.ParseFloat(spec[:len(spec)-1], 32); err == nil {
+			return full * float32(n) / 100
+		}
+	default:
+		if n, err := strconv.ParseFloat(spec, 32); err == nil {
+			return cell * float32(n)
+		}
+	}
+	return natural
+}
+
+func isAuto(spec string) bool {
+	return spec == "" || spec == "auto"
+}
+
+// shiftImages moves the images in the scroll region when it scrolls, dropping those scrolled out of it.
+// Images scrolled off the top of the main screen move into the scrollback, until it drops them too.
+func (s *Screen) shiftImages(rows int) {
+	top := s.scrollTop
+	if top == 0 && !s.altScreen {
+		top = -len(s.scrollback)
+	}
+	kept := s.images[:0]
+	for _, img := range s.images {
+		if img.row >= top && img.row <= s.scrollBottom {
+			img.row += rows
+			if img.row+img.height <= top || img.row > s.scrollBottom {
+				continue
+			}
+		}
+		kept = append(kept, img)
+	}
+	s.images = kept
+}
+
+// clearScreenImages removes the images that start on the screen, leaving those in the scrollback.
+func (s *Screen) clearScreenImages() {
+	kept := s.images[:0]
+	for _, img := range s.images {
+		if img.row < 0 {
+			kept = append(kept, img)
+		}
+	}
+	s.images = kept
+}
@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testImageData(t *testing.T, w, h int) string {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestOSC_ITerm2Image(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+
+	data := testImageData(t, 20, 10)
+	term.handleOutput([]byte("\x1b]1337;File=inline=1;width=3;height=2;preserveAspectRatio=0:" + data + "\a"))
+	assert.Equal(t, 1, len(term.screen.images))
+	img := term.screen.images[0]
+	assert.Equal(t, 0, img.row)
+	assert.Equal(t, 0, img.col)
+	assert.Equal(t, 3, img.width)
+	assert.Equal(t, 2, img.height)
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 3, term.screen.cursorCol)
+
+	term.handleOutput([]byte("\x1b]1337;File=name=eA==;size=4:" + data + "\a"))
+	assert.Equal(t, 1, len(term.screen.images)) // not inline, so not shown
+
+	term.handleOutput([]byte("\r\n\n\n\n"))
+	assert.Equal(t, -1, term.screen.images[0].row) // scrolled with the content
+
+	term.handleOutput([]byte("\x1b[2J"))
+	assert.Equal(t, 1, len(term.screen.images)) // still in the scrollback
+}
+
+func TestOSC_ITerm2ImageLimits(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+
+	data := testImageData(t, maxImageDimension+1, 1)
+	term.handleOutput([]byte("\x1b]1337;File=inline=1:" + data + "\a"))
+	assert.Equal(t, 0, len(term.screen.images))
+
+	_, err := decodeImage([]byte("not an image"))
+	assert.NotNil(t, err)
+}
+
+func TestImages_ScrollRegion(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+	data := testImageData(t, 20, 10)
+
+	term.handleOutput([]byte("\x1b]1337;File=inline=1;width=1;height=1:" + data + "\a"))
+	term.handleOutput([]byte("\x1b[4;1H\x1b]1337;File=inline=1;width=1;height=1:" + data + "\a"))
+	assert.Equal(t, 2, len(term.screen.images))
+
+	term.handleOutput([]byte("\x1b[3;5r\x1b[5;1H\n")) // scroll the region below the first image
+	assert.Equal(t, 2, len(term.screen.images))
+	assert.Equal(t, 0, term.screen.images[0].row)
+	assert.Equal(t, 2, term.screen.images[1].row)
+
+	term.handleOutput([]byte("\n")) // the second image leaves the region
+	assert.Equal(t, 1, len(term.screen.images))
+	assert.Equal(t, 0, term.screen.images[0].row)
+	assert.Empty(t, term.screen.scrollback)
+}
+
+func TestOSC_ITerm2Command(t *testing.T) {
+	term := New()
+	var got string
+	term.SetITerm2CommandHandler(func(cmd string) {
+		got = cmd
+	})
+
+	term.handleOutput([]byte("\x1b]1337;SetUserVar=foo=YmFy\a"))
+	assert.Equal(t, "SetUserVar=foo=YmFy", got)
+}
+
+func TestImageDimension(t *testing.T) {
+	assert.Equal(t, float32(40), imageDimension("", 40, 8, 800))
+	assert.Equal(t, float32(40), imageDimension("auto", 40, 8, 800))
+	assert.Equal(t, float32(24), imageDimension("3", 40, 8, 800))
+	assert.Equal(t, float32(100), imageDimension("100px", 40, 8, 800))
+	assert.Equal(t, float32(400), imageDimension("50%", 40, 8, 800))
+}
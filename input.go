@@ -10,27 +10,44 @@ import (
 
 // TypedRune is called when the user types a visible character
 func (t *Terminal) TypedRune(r rune) {
+	if t.copyMode { // keys are handled by TypedKey
+		return
+	}
+	if t.altSendsEscape && t.keyboardState.altPressed { // already sent by TypedShortcut
+		return
+	}
+	t.keystroke()
 	b := make([]byte, utf8.UTFMax)
 	size := utf8.EncodeRune(b, r)
 	_, _ = t.in.Write(b[:size])
+	t.echo(b[:size])
 }
 
 // TypedKey will be called if a non-printable keyboard event occurs
 func (t *Terminal) TypedKey(e *fyne.KeyEvent) {
+	if t.copyMode {
+		t.typeCopyModeKey(e)
+		return
+	}
+	t.keystroke()
+	switch e.Name {
+	case fyne.KeyReturn, fyne.KeyEnter:
+		t.echo([]byte{'\r', '\n'})
+	case fyne.KeyBackspace:
+		t.echo([]byte{asciiBackspace, ' ', asciiBackspace})
+	}
 	if t.keyboardState.shiftPressed {
 		t.keyTypedWithShift(e)
 		return
 	}
 
 	switch e.Name {
-	case fyne.KeyReturn:
-		_, _ = t.in.Write([]byte{'\r'})
-	case fyne.KeyEnter:
-		if t.newLineMode {
-			_, _ = t.in.Write([]byte{'\r'})
+	case fyne.KeyReturn, fyne.KeyEnter:
+		if t.screen.newLineMode { // LNM asks for a line feed after each carriage return
+			_, _ = t.in.Write([]byte{'\r', '\n'})
 			return
 		}
-		_, _ = t.in.Write([]byte{'\n'})
+		_, _ = t.in.Write([]byte{'\r'})
 	case fyne.KeyTab:
 		_, _ = t.in.Write([]byte{'\t'})
 	case fyne.KeyF1:
@@ -60,7 +77,11 @@ func (t *Terminal) TypedKey(e *fyne.KeyEvent) {
 	case fyne.KeyEscape:
 		_, _ = t.in.Write([]byte{asciiEscape})
 	case fyne.KeyBackspace:
-		_, _ = t.in.Write([]byte{asciiBackspace})
+		if t.screen.backarrowSendsBS {
+			_, _ = t.in.Write([]byte{asciiBackspace})
+			return
+		}
+		_, _ = t.in.Write([]byte{asciiDelete})
 	case fyne.KeyDelete:
 		_, _ = t.in.Write([]byte{asciiEscape, '[', '3', '~'})
 	case fyne.KeyUp, fyne.KeyDown, fyne.KeyLeft, fyne.KeyRight:
@@ -69,15 +90,37 @@ func (t *Terminal) TypedKey(e *fyne.KeyEvent) {
 		_, _ = t.in.Write([]byte{asciiEscape, '[', '5', '~'})
 	case fyne.KeyPageDown:
 		_, _ = t.in.Write([]byte{asciiEscape, '[', '6', '~'})
-	case fyne.KeyHome:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'H'})
+	case fyne.KeyHome, fyne.KeyEnd:
+		t.typeCursorKey(e.Name)
 	case fyne.KeyInsert:
 		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '~'})
-	case fyne.KeyEnd:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'F'})
 	}
 }
 
+// echo shows typed input in the terminal if local echo is turned on.
+// While the session is running the input is passed to run, so that it is handled in order with the output.
+func (t *Terminal) echo(b []byte) {
+	if !t.localEcho {
+		return
+	}
+
+	t.closeLock.Lock()
+	done := t.runDone
+	t.closeLock.Unlock()
+	if done != nil {
+		select {
+		case t.echoed <- append([]byte{}, b...):
+			return
+		case <-done: // the session has ended
+		}
+	}
+
+	t.screen.stateLock.Lock()
+	t.handleOutput(b)
+	t.screen.stateLock.Unlock()
+	t.scheduleRefresh()
+}
+
 func (t *Terminal) keyTypedWithShift(e *fyne.KeyEvent) {
 	switch e.Name {
 	case fyne.KeyF1:
@@ -141,6 +184,8 @@ func (t *Terminal) trackKeyboardState(down bool, e *fyne.KeyEvent) {
 		t.keyboardState.altPressed = down
 	case desktop.KeyControlRight:
 		t.keyboardState.ctrlPressed = down
+	case fyne.KeyInsert:
+		t.keyboardState.insertPressed = down
 	}
 }
 
@@ -156,15 +201,28 @@ func (t *Terminal) KeyUp(e *fyne.KeyEvent) {
 
 // FocusGained notifies the terminal that it has focus
 func (t *Terminal) FocusGained() {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
 	t.focused = true
 	t.Refresh()
 }
 
 // TypedShortcut handles key combinations, we pass them on to the tty.
+// On every platform Shift+Insert pastes and Ctrl+Insert copies the selection, these keys are not sent
+// to the application. Plain Insert is always sent, as CSI 2 ~.
 func (t *Terminal) TypedShortcut(s fyne.Shortcut) {
+	if t.typeInsertShortcut(s) {
+		return
+	}
 	if ds, ok := s.(*desktop.CustomShortcut); ok {
 		t.ShortcutHandler.TypedShortcut(s) // it's not clear how we can check if this consumed the event
 
+		if t.altSendsEscape && ds.Modifier&^fyne.KeyModifierShift == fyne.KeyModifierAlt {
+			t.keystroke()
+			t.typeAltKey(ds)
+			return
+		}
+
 		// handle CTRL+A to CTRL+_ and everything inbetween
 		if ds.Modifier == fyne.KeyModifierControl {
 			char := ds.KeyName[0]
@@ -176,6 +234,7 @@ func (t *Terminal) TypedShortcut(s fyne.Shortcut) {
 				off = 0
 				fallthrough
 			case char >= 'A' && char <= '_':
+				t.keystroke()
 				_, _ = t.in.Write([]byte{off})
 			}
 		}
@@ -204,8 +263,48 @@ func (t *Terminal) TypedShortcut(s fyne.Shortcut) {
 	}
 }
 
+// typeInsertShortcut handles the shortcuts that Fyne reports for Shift+Insert, Ctrl+Insert and Shift+Delete,
+// which would otherwise be confused with Ctrl+V, Ctrl+C and Ctrl+X. It returns true if the shortcut was handled.
+func (t *Terminal) typeInsertShortcut(s fyne.Shortcut) bool {
+	switch s.(type) {
+	case *fyne.ShortcutPaste:
+		if !t.keyboardState.insertPressed {
+			return false
+		}
+		if c := t.clipboard(); c != nil {
+			t.keystroke()
+			t.pasteText(c)
+		}
+	case *fyne.ShortcutCopy:
+		if !t.keyboardState.insertPressed {
+			return false
+		}
+		if c := t.clipboard(); c != nil {
+			t.copySelectedText(c)
+		}
+	case *fyne.ShortcutCut:
+		if !t.keyboardState.shiftPressed {
+			return false
+		}
+		t.keystroke()
+		t.keyTypedWithShift(&fyne.KeyEvent{Name: fyne.KeyDelete})
+	default:
+		return false
+	}
+	return true
+}
+
+// keystroke returns the view to the screen as the user types, unless disabled with SetScrollOnKeystroke.
+func (t *Terminal) keystroke() {
+	if t.scrollOnKeystroke {
+		t.ScrollToBottom()
+	}
+}
+
 // FocusLost tells the terminal it no longer has focus
 func (t *Terminal) FocusLost() {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
 	t.focused = false
 	t.Refresh()
 }
@@ -215,9 +314,27 @@ func (t *Terminal) Focused() bool {
 	return t.focused
 }
 
+// typeAltKey sends ESC followed by the character of a key pressed with Alt, as xterm's metaSendsEscape.
+func (t *Terminal) typeAltKey(s *desktop.CustomShortcut) {
+	if len(s.KeyName) != 1 {
+		if s.KeyName == fyne.KeySpace {
+			_, _ = t.in.Write([]byte{asciiEscape, ' '})
+		}
+		return
+	}
+
+	char := s.KeyName[0]
+	if s.Modifier&fyne.KeyModifierShift == 0 && char >= 'A' && char <= 'Z' {
+		char += 'a' - 'A'
+	}
+	_, _ = t.in.Write([]byte{asciiEscape, char})
+}
+
+// typeCursorKey sends an arrow, Home or End key, as SS3 sequences in application cursor key mode
+// or CSI sequences otherwise.
 func (t *Terminal) typeCursorKey(key fyne.KeyName) {
 	cursorPrefix := byte('[')
-	if t.bufferMode {
+	if t.screen.applicationCursorKeys {
 		cursorPrefix = 'O'
 	}
 
@@ -230,5 +347,9 @@ func (t *Terminal) typeCursorKey(key fyne.KeyName) {
 		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'D'})
 	case fyne.KeyRight:
 		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'C'})
+	case fyne.KeyHome:
+		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'H'})
+	case fyne.KeyEnd:
+		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'F'})
 	}
 }
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"io"
 	"testing"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
 )
 
 // NopCloser returns a WriteCloser with a no-op Close method wrapping
@@ -26,7 +29,7 @@ func (n nopCloser) Close() error {
 func TestTerminal_TypedKey(t *testing.T) {
 	tests := map[string]struct {
 		key          fyne.KeyName
-		bufferMode   bool
+		appCursor    bool
 		shiftPressed bool
 		want         []byte
 	}{
@@ -67,25 +70,32 @@ func TestTerminal_TypedKey(t *testing.T) {
 
 		"PageUp":    {fyne.KeyPageUp, false, false, []byte{asciiEscape, '[', '5', '~'}},
 		"PageDown":  {fyne.KeyPageDown, false, false, []byte{asciiEscape, '[', '6', '~'}},
-		"Home":      {fyne.KeyHome, false, false, []byte{asciiEscape, 'O', 'H'}},
+		"Home":      {fyne.KeyHome, false, false, []byte{asciiEscape, '[', 'H'}},
 		"Insert":    {fyne.KeyInsert, false, false, []byte{asciiEscape, '[', '2', '~'}},
 		"Delete":    {fyne.KeyDelete, false, false, []byte{asciiEscape, '[', '3', '~'}},
-		"End":       {fyne.KeyEnd, false, false, []byte{asciiEscape, 'O', 'F'}},
-		"Enter":     {fyne.KeyEnter, false, false, []byte{'\n'}}, // Modify as needed for Windows or bufferMode
+		"End":       {fyne.KeyEnd, false, false, []byte{asciiEscape, '[', 'F'}},
+		"Enter":     {fyne.KeyEnter, false, false, []byte{'\r'}},
 		"Tab":       {fyne.KeyTab, false, false, []byte{'\t'}},
 		"Escape":    {fyne.KeyEscape, false, false, []byte{asciiEscape}},
-		"Backspace": {fyne.KeyBackspace, false, false, []byte{asciiBackspace}},
+		"Backspace": {fyne.KeyBackspace, false, false, []byte{asciiDelete}},
 		"Up":        {fyne.KeyUp, false, false, []byte{asciiEscape, '[', 'A'}},
 		"Down":      {fyne.KeyDown, false, false, []byte{asciiEscape, '[', 'B'}},
 		"Left":      {fyne.KeyLeft, false, false, []byte{asciiEscape, '[', 'D'}},
 		"Right":     {fyne.KeyRight, false, false, []byte{asciiEscape, '[', 'C'}},
+
+		"Application Up":    {fyne.KeyUp, true, false, []byte{asciiEscape, 'O', 'A'}},
+		"Application Down":  {fyne.KeyDown, true, false, []byte{asciiEscape, 'O', 'B'}},
+		"Application Left":  {fyne.KeyLeft, true, false, []byte{asciiEscape, 'O', 'D'}},
+		"Application Right": {fyne.KeyRight, true, false, []byte{asciiEscape, 'O', 'C'}},
+		"Application Home":  {fyne.KeyHome, true, false, []byte{asciiEscape, 'O', 'H'}},
+		"Application End":   {fyne.KeyEnd, true, false, []byte{asciiEscape, 'O', 'F'}},
 	}
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			// Creating a mock terminal
 			inBuffer := bytes.NewBuffer([]byte{})
-			term := &Terminal{in: NopCloser(inBuffer), bufferMode: tt.bufferMode}
+			term := &Terminal{screen: &Screen{applicationCursorKeys: tt.appCursor}, in: NopCloser(inBuffer)}
 			term.keyboardState.shiftPressed = tt.shiftPressed
 			keyEvent := &fyne.KeyEvent{Name: tt.key}
 
@@ -99,6 +109,41 @@ func TestTerminal_TypedKey(t *testing.T) {
 	}
 }
 
+func TestTerminal_TypedKey_ApplicationCursorKeys(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+
+	term.handleOutput([]byte(esc("[?1h")))
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyUp})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyHome})
+	assert.Equal(t, esc("OA")+esc("OH"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[?1l")))
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyUp})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyHome})
+	assert.Equal(t, esc("[A")+esc("[H"), inBuffer.String())
+}
+
+func TestTerminal_TypedKey_Backspace(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+
+	term.SetBackspaceSendsDelete(false)
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	term.handleOutput([]byte(esc("[?67l")))
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	term.handleOutput([]byte(esc("[?67h")))
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+
+	want := []byte{asciiBackspace, asciiDelete, asciiBackspace}
+	if got := inBuffer.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("TypedKey() = %v, want %v", got, want)
+	}
+}
+
 func TestTerminal_TypedKey_LineMode(t *testing.T) {
 	tests := map[string]struct {
 		key         fyne.KeyName
@@ -106,17 +151,17 @@ func TestTerminal_TypedKey_LineMode(t *testing.T) {
 		want        []byte
 	}{
 
-		"Enter":                 {fyne.KeyEnter, false, []byte{'\n'}},
-		"Enter with line mode":  {fyne.KeyEnter, true, []byte{'\r'}},
+		"Enter":                 {fyne.KeyEnter, false, []byte{'\r'}},
+		"Enter with line mode":  {fyne.KeyEnter, true, []byte{'\r', '\n'}},
 		"Return":                {fyne.KeyReturn, false, []byte{'\r'}},
-		"Return with line mode": {fyne.KeyReturn, true, []byte{'\r'}},
+		"Return with line mode": {fyne.KeyReturn, true, []byte{'\r', '\n'}},
 	}
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			// Creating a mock terminal
 			inBuffer := bytes.NewBuffer([]byte{})
-			term := &Terminal{in: NopCloser(inBuffer), newLineMode: tt.newLineMode}
+			term := &Terminal{screen: &Screen{newLineMode: tt.newLineMode}, in: NopCloser(inBuffer)}
 			keyEvent := &fyne.KeyEvent{Name: tt.key}
 
 			term.TypedKey(keyEvent)
@@ -129,10 +174,26 @@ func TestTerminal_TypedKey_LineMode(t *testing.T) {
 	}
 }
 
+func TestTerminal_TypedKey_NewLineModeEscape(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+
+	term.handleOutput([]byte(esc("[20h")))
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	assert.Equal(t, "\r\n", inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[20l")))
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	assert.Equal(t, "\r", inBuffer.String())
+}
+
 func TestTerminal_TypedShortcut(t *testing.T) {
 	tests := map[string]struct {
-		shortcut fyne.Shortcut
-		want     []byte
+		shortcut       fyne.Shortcut
+		altSendsEscape bool
+		want           []byte
 	}{
 		"LeftOption+U": {
 			shortcut: &desktop.CustomShortcut{
@@ -140,6 +201,27 @@ func TestTerminal_TypedShortcut(t *testing.T) {
 				KeyName:  fyne.KeyU},
 			want: []byte{},
 		},
+		"Alt+F sends escape": {
+			shortcut: &desktop.CustomShortcut{
+				Modifier: fyne.KeyModifierAlt,
+				KeyName:  fyne.KeyF},
+			altSendsEscape: true,
+			want:           []byte{asciiEscape, 'f'},
+		},
+		"Alt+Shift+F sends escape": {
+			shortcut: &desktop.CustomShortcut{
+				Modifier: fyne.KeyModifierAlt | fyne.KeyModifierShift,
+				KeyName:  fyne.KeyF},
+			altSendsEscape: true,
+			want:           []byte{asciiEscape, 'F'},
+		},
+		"Alt+. sends escape": {
+			shortcut: &desktop.CustomShortcut{
+				Modifier: fyne.KeyModifierAlt,
+				KeyName:  fyne.KeyPeriod},
+			altSendsEscape: true,
+			want:           []byte{asciiEscape, '.'},
+		},
 		"Control+@": {
 			shortcut: &desktop.CustomShortcut{
 				Modifier: fyne.KeyModifierControl,
@@ -176,7 +258,7 @@ func TestTerminal_TypedShortcut(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			// Creating a mock terminal
 			inBuffer := bytes.NewBuffer([]byte{})
-			term := &Terminal{in: NopCloser(inBuffer)}
+			term := &Terminal{in: NopCloser(inBuffer), altSendsEscape: tt.altSendsEscape}
 
 			term.TypedShortcut(tt.shortcut)
 
@@ -187,3 +269,74 @@ func TestTerminal_TypedShortcut(t *testing.T) {
 		})
 	}
 }
+
+func TestTerminal_TypedShortcut_Insert(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	w := test.NewWindow(term)
+	defer w.Close()
+	w.Clipboard().SetContent("pasted")
+
+	term.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.KeyInsert})
+	term.TypedShortcut(&fyne.ShortcutPaste{})
+	term.KeyUp(&fyne.KeyEvent{Name: fyne.KeyInsert})
+	assert.Equal(t, "pasted", inBuffer.String())
+
+	inBuffer.Reset()
+	term.TypedShortcut(&fyne.ShortcutCut{})
+	term.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	assert.Equal(t, []byte{asciiEscape, '[', '3', ';', '2', '~'}, inBuffer.Bytes())
+}
+
+func TestTerminal_LocalEcho(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+
+	term.TypedRune('a')
+	assert.Equal(t, "", term.screen.content.Text())
+
+	term.SetLocalEcho(true)
+	assert.True(t, term.LocalEcho())
+	term.TypedRune('b')
+	term.TypedRune('c')
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	term.TypedRune('d')
+	assert.Equal(t, "b\nd", term.screen.content.Text())
+	assert.Equal(t, "abc\x7f\rd", inBuffer.String())
+}
+
+func TestTerminal_LocalEchoDuringOutput(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 20, 2
+	term.screen.scrollBottom = 1
+	term.SetLocalEcho(true)
+	r, w := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- term.RunWithConnection(NopCloser(&bytes.Buffer{}), r)
+	}()
+
+	text := func() string {
+		term.screen.stateLock.Lock()
+		defer term.screen.stateLock.Unlock()
+		return term.screen.content.Text()
+	}
+	_, _ = w.Write([]byte("$ \xe4\xb8")) // the rest of the character has not arrived
+	assert.Eventually(t, func() bool { return text() == "$" }, time.Second, time.Millisecond)
+	term.TypedRune('x')
+	_, _ = w.Write([]byte("\x96"))
+	assert.Eventually(t, func() bool { return text() == "$ x世" }, time.Second, time.Millisecond)
+	term.TypedRune('y')
+	_ = w.Close()
+	assert.Nil(t, <-done)
+	assert.Equal(t, "$ x世y", term.screen.content.Text())
+}
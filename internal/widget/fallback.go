@@ -0,0 +1,133 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const maxCachedGlyphs = 1024
+
+// fallbackFonts finds and draws the glyphs that the monospace font does not have using other fonts.
+type fallbackFonts struct {
+	resources []fyne.Resource
+	primary   *sfnt.Font
+	fonts     []*sfnt.Font
+
+	lock   sync.Mutex
+	buf    sfnt.Buffer
+	runes  map[rune]int // the index into fonts for each rune looked up, or -1 if no fallback is used
+	glyphs map[glyphKey]image.Image
+}
+
+type glyphKey struct {
+	font          int
+	r             rune
+	color         color.NRGBA
+	size          float32
+	width, height int
+}
+
+func newFallbackFonts(resources []fyne.Resource) *fallbackFonts {
+	f := &fallbackFonts{resources: resources, runes: make(map[rune]int), glyphs: make(map[glyphKey]image.Image)}
+	if mono := theme.TextMonospaceFont(); mono != nil {
+		f.primary, _ = sfnt.Parse(mono.Content())
+	}
+	for _, res := range resources {
+		if res == nil {
+			continue
+		}
+		if parsed, err := sfnt.Parse(res.Content()); err == nil {
+			f.fonts = append(f.fonts, parsed)
+		} else {
+			fyne.LogError("Failed to load fallback font "+res.Name(), err)
+		}
+	}
+	return f
+}
+
+// matches returns true if these fonts were loaded from the given resources.
+func (f *fallbackFonts) matches(resources []fyne.Resource) bool {
+	if len(f.resources) != len(resources) {
+		return false
+	}
+	for i, res := range resources {
+		if f.resources[i] != res {
+			return false
+		}
+	}
+	return true
+}
+
+// fontFor returns the index of the fallback font to draw r with, or -1 if the monospace font should be used.
+func (f *fallbackFonts) fontFor(r rune) int {
+	if r < 0x80 {
+		return -1 // every monospace font has ASCII
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if i, ok := f.runes[r]; ok {
+		return i
+	}
+	i := -1
+	if !f.hasGlyph(f.primary, r) {
+		for j, fallback := range f.fonts {
+			if f.hasGlyph(fallback, r) {
+				i = j
+				break
+			}
+		}
+	}
+	f.runes[r] = i
+	return i
+}
+
+func (f *fallbackFonts) hasGlyph(face *sfnt.Font, r rune) bool {
+	if face == nil {
+		return false
+	}
+	i, err := face.GlyphIndex(&f.buf, r)
+	return err == nil && i != 0
+}
+
+// glyph returns an image of width by height pixels with r drawn in the centre using the fallback font at index.
+// The size is in pixels, already scaled for the canvas.
+func (f *fallbackFonts) glyph(index int, r rune, c color.Color, size float32, width, height int) image.Image {
+	key := glyphKey{font: index, r: r, color: color.NRGBAModel.Convert(c).(color.NRGBA), size: size,
+		width: width, height: height}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if img, ok := f.glyphs[key]; ok {
+		return img
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	face, err := opentype.NewFace(f.fonts[index], &opentype.FaceOptions{Size: float64(size), DPI: 72,
+		Hinting: font.HintingFull})
+	if err != nil {
+		fyne.LogError("Failed to create fallback font face", err)
+		return img
+	}
+	defer face.Close()
+
+	m := face.Metrics()
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face}
+	adv := d.MeasureString(string(r))
+	d.Dot = fixed.Point26_6{X: (fixed.I(width) - adv) / 2,
+		Y: (fixed.I(height)-m.Ascent-m.Descent)/2 + m.Ascent}
+	d.DrawString(string(r))
+
+	if len(f.glyphs) >= maxCachedGlyphs {
+		f.glyphs = make(map[glyphKey]image.Image)
+	}
+	f.glyphs[key] = img
+	return img
+}
@@ -5,40 +5,166 @@ import (
 	"image/color"
 	"math"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2/widget"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 )
 
 const (
+	ligatureSymbols       = "!#$%&*+-./:;<=>?@\\^_|~" // the characters that programming fonts join
+	ligatureTolerance     = 0.5                       // how far, in pixels, a joined run may differ from its cells
 	textAreaSpaceSymbol   = '·'
 	textAreaTabSymbol     = '→'
 	textAreaNewLineSymbol = '↵'
 	blinkingInterval      = 500 * time.Millisecond
+	overlineWidth         = 1
+
+	// WideCharPadding is stored in the cell after a double width character.
+	// It is not drawn, as the wide character covers it, and is skipped when extracting text.
+	WideCharPadding rune = -1
 )
 
+// defaultStyle is the style of cells drawn without any content.
+// The TextGrid globals are not used, as every TextGrid renderer overwrites them.
+var defaultStyle widget.TextGridStyle = &widget.CustomTextGridStyle{}
+
 // TermGrid is a monospaced grid of characters.
 // This is designed to be used by our terminal emulator.
 type TermGrid struct {
 	widget.TextGrid
+
+	// TextSize is the size of text in the grid, if it is 0 the theme text size is used.
+	TextSize float32
+	// LineSpacing is extra space added to the height of each row, it may be negative to tighten lines.
+	LineSpacing float32
+	// BlinkDisabled draws blinking cells steadily and stops the blink timer.
+	BlinkDisabled bool
+	// Inverted swaps the text and background colours of every cell, for example to show a visual bell.
+	Inverted bool
+	// DefaultBackground fills cells that have no background colour, if it is nil they are transparent.
+	DefaultBackground color.Color
+	// ForcedForeground and ForcedBackground, when both are set, replace the colours of every cell.
+	ForcedForeground, ForcedBackground color.Color
+	// FallbackFonts are used, in order, for characters that the monospace font does not have.
+	FallbackFonts []fyne.Resource
+	// Ligatures draws runs of symbols with the same style together, so that the font can join them.
+	Ligatures bool
+
+	dirtyLock sync.Mutex
+	dirtyRows map[int]bool
 }
 
 // CreateRenderer is a private method to Fyne which links this widget to it's renderer
 func (t *TermGrid) CreateRenderer() fyne.WidgetRenderer {
 	t.ExtendBaseWidget(t)
-	render := &termGridRenderer{text: t}
+	render := &termGridRenderer{text: t, fallbackLayer: container.NewWithoutLayout(),
+		ligatureLayer: container.NewWithoutLayout(), decorationLayer: container.NewWithoutLayout(),
+		overlines: make(map[int]*canvas.Rectangle)}
 	render.updateCellSize()
-	// N.B these global variables are not a good idea.
-	widget.TextGridStyleDefault = &widget.CustomTextGridStyle{}
-	widget.TextGridStyleWhitespace = &widget.CustomTextGridStyle{FGColor: theme.DisabledColor()}
 
 	return render
 }
 
+// isBlank returns true if the cell shows nothing, being an unset rune or a space without a background colour.
+// A selection highlight does not count as a background colour.
+func isBlank(cell widget.TextGridCell) bool {
+	if cell.Rune != ' ' && cell.Rune != 0 {
+		return false
+	}
+	if s, ok := cell.Style.(*TermTextGridStyle); ok && s != nil {
+		return s.OriginalBackgroundColor == nil
+	}
+	return cell.Style == nil || cell.Style.BackgroundColor() == nil
+}
+
+// SetRow updates the specified row of the grid's contents and marks it to be redrawn.
+// Unlike the TextGrid method this does not redraw immediately, the row is drawn on the next Refresh.
+func (t *TermGrid) SetRow(row int, content widget.TextGridRow) {
+	if row < 0 {
+		return
+	}
+	for len(t.Rows) <= row {
+		t.Rows = append(t.Rows, widget.TextGridRow{})
+	}
+
+	t.Rows[row] = content
+	t.MarkRowDirty(row)
+}
+
+// SetCell sets a grid data to the cell at named row and column and marks the row to be redrawn.
+// Unlike the TextGrid method this does not redraw immediately, the row is drawn on the next Refresh.
+func (t *TermGrid) SetCell(row, col int, cell widget.TextGridCell) {
+	if row < 0 || col < 0 {
+		return
+	}
+	for len(t.Rows) <= row {
+		t.Rows = append(t.Rows, widget.TextGridRow{})
+	}
+	for len(t.Rows[row].Cells) <= col {
+		t.Rows[row].Cells = append(t.Rows[row].Cells, widget.TextGridCell{})
+	}
+
+	t.Rows[row].Cells[col] = cell
+	t.MarkRowDirty(row)
+}
+
+// MarkRowDirty records that a row has changed so that it is redrawn on the next Refresh.
+// Code that modifies Rows directly must call this, as unchanged rows are not redrawn.
+func (t *TermGrid) MarkRowDirty(row int) {
+	t.MarkRowsDirty(row, row)
+}
+
+// MarkRowsDirty records that the rows from startRow to endRow (inclusive) have changed.
+func (t *TermGrid) MarkRowsDirty(startRow, endRow int) {
+	t.dirtyLock.Lock()
+	defer t.dirtyLock.Unlock()
+
+	if startRow > endRow {
+		startRow, endRow = endRow, startRow
+	}
+	if t.dirtyRows == nil {
+		t.dirtyRows = make(map[int]bool)
+	}
+	for i := startRow; i <= endRow; i++ {
+		t.dirtyRows[i] = true
+	}
+}
+
+func (t *TermGrid) takeDirtyRows() map[int]bool {
+	t.dirtyLock.Lock()
+	defer t.dirtyLock.Unlock()
+
+	rows := t.dirtyRows
+	t.dirtyRows = nil
+	return rows
+}
+
+// CellTextSize returns the size of text that will be drawn in each cell.
+func (t *TermGrid) CellTextSize() float32 {
+	if t.TextSize > 0 {
+		return t.TextSize
+	}
+	return theme.TextSize()
+}
+
+// CellSize returns the size of each cell in the grid, including any line spacing.
+// The size is rounded to whole units so that backgrounds are seamless.
+func (t *TermGrid) CellSize() fyne.Size {
+	size := fyne.MeasureText("M", t.CellTextSize(), fyne.TextStyle{Monospace: true})
+	size.Height = fyne.Max(size.Height+t.LineSpacing, size.Height/2) // don't let rows collapse
+
+	size.Width = float32(math.Round(float64(size.Width)))
+	size.Height = float32(math.Round(float64(size.Height)))
+	return size
+}
+
 // NewTermGrid creates a new empty TextGrid widget.
 func NewTermGrid() *TermGrid {
 	grid := &TermGrid{}
@@ -46,17 +172,50 @@ func NewTermGrid() *TermGrid {
 	return grid
 }
 
+// gridState is everything other than the cell content that affects how every cell is drawn.
+type gridState struct {
+	cellSize                    fyne.Size
+	cols, rows                  int
+	fg, bg, defaultBG           color.Color
+	forcedFG, forcedBG          color.Color
+	inverted, blinkDisabled     bool
+	lineNumbers, showWhitespace bool
+	ligatures                   bool
+}
+
 type termGridRenderer struct {
-	text *TermGrid
+	text  *TermGrid
+	drawn *gridState
 
 	cols, rows int
 
 	cellSize     fyne.Size
 	objects      []fyne.CanvasObject
+	layers       []fyne.CanvasObject
 	current      fyne.Canvas
 	blink        bool
-	shouldBlink  bool
+	cellBlinks   []bool // which cell objects show blinking text
+	blinkCount   int    // how many entries in cellBlinks are true
 	tickerCancel context.CancelFunc
+
+	fallback      *fallbackFonts
+	fallbackLock  sync.Mutex
+	fallbackCells map[int]*canvas.Image // glyphs drawn with a fallback font, by cell
+	fallbackLayer *fyne.Container
+
+	ligatureLock  sync.Mutex
+	ligatures     map[int][]ligatureRun // the joined runs of each row
+	ligatureLayer *fyne.Container
+
+	decorationLock  sync.Mutex
+	overlines       map[int]*canvas.Rectangle // lines drawn above the cells with the overline attribute
+	decorationLayer *fyne.Container
+}
+
+// ligatureRun is text drawn across several cells so that the font can draw ligatures.
+type ligatureRun struct {
+	text     *canvas.Text
+	row, col int
 }
 
 func (t *termGridRenderer) appendTextCell(str rune) {
@@ -68,7 +227,7 @@ func (t *termGridRenderer) appendTextCell(str rune) {
 }
 
 func (t *termGridRenderer) setCellRune(str rune, pos int, style widget.TextGridStyle) {
-	if str == 0 {
+	if str == 0 || str == WideCharPadding {
 		str = ' '
 	}
 	fg := theme.ForegroundColor()
@@ -78,17 +237,50 @@ func (t *termGridRenderer) setCellRune(str rune, pos int, style widget.TextGridS
 	bg := color.Color(color.Transparent)
 	if style != nil && style.BackgroundColor() != nil {
 		bg = style.BackgroundColor()
+	} else if t.text.DefaultBackground != nil {
+		bg = t.text.DefaultBackground
+	}
+	if t.text.ForcedForeground != nil && t.text.ForcedBackground != nil {
+		fg, bg = t.text.ForcedForeground, t.text.ForcedBackground
+		if s, ok := style.(*TermTextGridStyle); ok && s != nil && s.Highlighted {
+			fg, bg = bg, fg // keep selected text visible
+		}
 	}
 
-	if s, ok := style.(*TermTextGridStyle); ok && s != nil && s.BlinkEnabled {
-		t.shouldBlink = true
+	blinks := false
+	if s, ok := style.(*TermTextGridStyle); ok && s != nil && s.BlinkEnabled && !t.text.BlinkDisabled {
+		blinks = true
 		if t.blink {
 			fg = bg
 		}
 	}
+	t.setCellBlinks(pos, blinks)
+	if t.text.Inverted {
+		if bg == color.Transparent {
+			bg = theme.BackgroundColor()
+		}
+		fg, bg = bg, fg
+	}
+	overline := false
+	if s, ok := style.(*TermTextGridStyle); ok && s != nil {
+		overline = s.Overline
+		if s.Concealed {
+			fg = bg // the rune is kept, so it can still be selected and copied
+		}
+	}
+	t.setOverline(pos, overline, fg)
+
+	if t.fallback != nil {
+		if index := t.fallback.fontFor(str); index >= 0 {
+			t.setFallbackGlyph(pos, index, str, fg)
+			str = ' '
+		} else {
+			t.removeFallbackGlyph(pos)
+		}
+	}
 
 	text := t.objects[pos*2+1].(*canvas.Text)
-	text.TextSize = theme.TextSize()
+	text.TextSize = t.text.CellTextSize()
 
 	newStr := string(str)
 	if text.Text != newStr || text.Color != fg {
@@ -111,14 +303,131 @@ func (t *termGridRenderer) addCellsIfRequired() {
 	}
 	for i := len(t.objects); i < cellCount*2; i += 2 {
 		t.appendTextCell(' ')
+		t.cellBlinks = append(t.cellBlinks, false)
+	}
+
+	// draw all backgrounds before the text so that wide characters are not covered by the following cell
+	t.layers = make([]fyne.CanvasObject, 0, len(t.objects))
+	for i := 0; i < len(t.objects); i += 2 {
+		t.layers = append(t.layers, t.objects[i])
+	}
+	for i := 1; i < len(t.objects); i += 2 {
+		t.layers = append(t.layers, t.objects[i])
+	}
+	t.layers = append(t.layers, t.ligatureLayer, t.fallbackLayer, t.decorationLayer)
+}
+
+// setOverline shows or hides the line above the cell at pos, drawn in the text colour.
+func (t *termGridRenderer) setOverline(pos int, show bool, fg color.Color) {
+	t.decorationLock.Lock()
+	defer t.decorationLock.Unlock()
+	line, ok := t.overlines[pos]
+	if !show {
+		if !ok {
+			return
+		}
+		delete(t.overlines, pos)
+		for i, o := range t.decorationLayer.Objects {
+			if o == line {
+				t.decorationLayer.Objects = append(t.decorationLayer.Objects[:i], t.decorationLayer.Objects[i+1:]...)
+				break
+			}
+		}
+		t.refresh(t.decorationLayer)
+		return
+	}
+
+	if ok {
+		if line.FillColor != fg {
+			line.FillColor = fg
+			t.refresh(line)
+		}
+		return
+	}
+	line = canvas.NewRectangle(fg)
+	line.Resize(fyne.NewSize(t.cellSize.Width, overlineWidth))
+	line.Move(t.cellPosition(pos))
+	t.overlines[pos] = line
+	t.decorationLayer.Objects = append(t.decorationLayer.Objects, line)
+	t.refresh(t.decorationLayer)
+}
+
+// setFallbackGlyph shows the rune at pos using the fallback font at index.
+func (t *termGridRenderer) setFallbackGlyph(pos, index int, r rune, fg color.Color) {
+	scale := float32(1)
+	if t.current != nil {
+		scale = t.current.Scale()
+	}
+	img := t.fallback.glyph(index, r, fg, t.text.CellTextSize()*scale,
+		int(math.Ceil(float64(t.cellSize.Width*scale))), int(math.Ceil(float64(t.cellSize.Height*scale))))
+
+	t.fallbackLock.Lock()
+	defer t.fallbackLock.Unlock()
+	if obj, ok := t.fallbackCells[pos]; ok {
+		if obj.Image != img {
+			obj.Image = img
+			t.refresh(obj)
+		}
+		return
+	}
+
+	obj := canvas.NewImageFromImage(img)
+	obj.FillMode = canvas.ImageFillStretch
+	obj.Resize(t.cellSize)
+	obj.Move(t.cellPosition(pos))
+	t.fallbackCells[pos] = obj
+	t.fallbackLayer.Objects = append(t.fallbackLayer.Objects, obj)
+	t.refresh(t.fallbackLayer)
+}
+
+// removeFallbackGlyph stops showing a fallback font glyph at pos, if there is one.
+func (t *termGridRenderer) removeFallbackGlyph(pos int) {
+	t.fallbackLock.Lock()
+	defer t.fallbackLock.Unlock()
+	obj, ok := t.fallbackCells[pos]
+	if !ok {
+		return
+	}
+
+	delete(t.fallbackCells, pos)
+	for i, o := range t.fallbackLayer.Objects {
+		if o == obj {
+			t.fallbackLayer.Objects = append(t.fallbackLayer.Objects[:i], t.fallbackLayer.Objects[i+1:]...)
+			break
+		}
+	}
+	t.refresh(t.fallbackLayer)
+}
+
+// updateFallbackFonts loads the fallback fonts if they have changed, returning true if they did.
+func (t *termGridRenderer) updateFallbackFonts() bool {
+	if t.fallback == nil && len(t.text.FallbackFonts) == 0 ||
+		t.fallback != nil && t.fallback.matches(t.text.FallbackFonts) {
+		return false
 	}
+
+	t.fallback = nil
+	if len(t.text.FallbackFonts) > 0 {
+		t.fallback = newFallbackFonts(t.text.FallbackFonts)
+	}
+	t.fallbackLock.Lock()
+	t.fallbackCells = make(map[int]*canvas.Image)
+	t.fallbackLayer.Objects = nil
+	t.fallbackLock.Unlock()
+	return true
+}
+
+func (t *termGridRenderer) cellPosition(pos int) fyne.Position {
+	if t.cols == 0 {
+		return fyne.NewPos(0, 0)
+	}
+	return fyne.NewPos(t.cellSize.Width*float32(pos%t.cols), t.cellSize.Height*float32(pos/t.cols))
 }
 
 func (t *termGridRenderer) refreshGrid() {
 	line := 1
 	x := 0
-	// reset shouldBlink which can be set by setCellRune if a cell with BlinkEnabled is found
-	t.shouldBlink = false
+	whitespace := &widget.CustomTextGridStyle{FGColor: theme.DisabledColor()}
 
 	for rowIndex, row := range t.text.Rows {
 		i := 0
@@ -126,16 +435,16 @@ func (t *termGridRenderer) refreshGrid() {
 			lineStr := []rune(strconv.Itoa(line))
 			pad := t.lineNumberWidth() - len(lineStr)
 			for ; i < pad; i++ {
-				t.setCellRune(' ', x, widget.TextGridStyleWhitespace) // padding space
+				t.setCellRune(' ', x, whitespace) // padding space
 				x++
 			}
 			for c := 0; c < len(lineStr); c++ {
-				t.setCellRune(lineStr[c], x, widget.TextGridStyleDefault) // line numbers
+				t.setCellRune(lineStr[c], x, defaultStyle) // line numbers
 				i++
 				x++
 			}
 
-			t.setCellRune('|', x, widget.TextGridStyleWhitespace) // last space
+			t.setCellRune('|', x, whitespace) // last space
 			i++
 			x++
 		}
@@ -150,11 +459,11 @@ func (t *termGridRenderer) refreshGrid() {
 				}
 
 				if r.Style != nil && r.Style.BackgroundColor() != nil {
-					whitespaceBG := &widget.CustomTextGridStyle{FGColor: widget.TextGridStyleWhitespace.TextColor(),
+					whitespaceBG := &widget.CustomTextGridStyle{FGColor: whitespace.TextColor(),
 						BGColor: r.Style.BackgroundColor()}
 					t.setCellRune(sym, x, whitespaceBG) // whitespace char
 				} else {
-					t.setCellRune(sym, x, widget.TextGridStyleWhitespace) // whitespace char
+					t.setCellRune(sym, x, whitespace) // whitespace char
 				}
 			} else {
 				t.setCellRune(r.Rune, x, r.Style) // regular char
@@ -163,25 +472,192 @@ func (t *termGridRenderer) refreshGrid() {
 			x++
 		}
 		if t.text.ShowWhitespace && i < t.cols && rowIndex < len(t.text.Rows)-1 {
-			t.setCellRune(textAreaNewLineSymbol, x, widget.TextGridStyleWhitespace) // newline
+			t.setCellRune(textAreaNewLineSymbol, x, whitespace) // newline
 			i++
 			x++
 		}
 		for ; i < t.cols; i++ {
-			t.setCellRune(' ', x, widget.TextGridStyleDefault) // blanks
+			t.setCellRune(' ', x, defaultStyle) // blanks
 			x++
 		}
 
 		line++
 	}
 	for ; x < len(t.objects)/2; x++ {
-		t.setCellRune(' ', x, widget.TextGridStyleDefault) // trailing cells and blank lines
+		t.setCellRune(' ', x, defaultStyle) // trailing cells and blank lines
+	}
+
+	t.ligatureLock.Lock()
+	t.ligatures = make(map[int][]ligatureRun)
+	t.ligatureLayer.Objects = nil
+	for row := 0; row < t.rows; row++ {
+		t.updateLigatures(row)
+	}
+	t.ligatureLock.Unlock()
+	t.refresh(t.ligatureLayer)
+	t.updateBlinkTimer()
+}
+
+// refreshRows redraws only the given rows, the caller must be sure that nothing else has changed.
+func (t *termGridRenderer) refreshRows(rows map[int]bool) {
+	for rowIndex := range rows {
+		if rowIndex < 0 || rowIndex >= t.rows {
+			continue
+		}
+
+		x := rowIndex * t.cols
+		i := 0
+		if rowIndex < len(t.text.Rows) {
+			for _, r := range t.text.Rows[rowIndex].Cells {
+				if i >= t.cols { // would be an overflow - bad
+					break
+				}
+				t.setCellRune(r.Rune, x, r.Style)
+				i++
+				x++
+			}
+		}
+		for ; i < t.cols; i++ {
+			t.setCellRune(' ', x, defaultStyle) // blanks
+			x++
+		}
 	}
 
+	t.ligatureLock.Lock()
+	for rowIndex := range rows {
+		if rowIndex >= 0 && rowIndex < t.rows {
+			t.updateLigatures(rowIndex)
+		}
+	}
+	t.ligatureLock.Unlock()
+	t.refresh(t.ligatureLayer)
+	t.updateBlinkTimer()
+}
+
+// updateLigatures joins the runs of symbols in a row that has just been drawn, if ligatures are enabled.
+// The caller must hold ligatureLock.
+func (t *termGridRenderer) updateLigatures(row int) {
+	if old, ok := t.ligatures[row]; ok {
+		objects := t.ligatureLayer.Objects[:0]
+		for _, o := range t.ligatureLayer.Objects {
+			if !containsRun(old, o) {
+				objects = append(objects, o)
+			}
+		}
+		t.ligatureLayer.Objects = objects
+		delete(t.ligatures, row)
+	}
+	if !t.text.Ligatures || t.text.ShowLineNumbers || t.text.ShowWhitespace {
+		return
+	}
+
+	start := -1
+	for col := 0; col <= t.cols; col++ {
+		if start >= 0 && col < t.cols && t.canJoin(row, start, col) {
+			continue
+		}
+		if start >= 0 && col-start > 1 {
+			t.addLigatureRun(row, start, col)
+		}
+		start = -1
+		if col < t.cols && t.canJoin(row, col, col) {
+			start = col
+		}
+	}
+}
+
+// canJoin returns true if the cell at col could be part of a ligature that starts at the cell at start.
+func (t *termGridRenderer) canJoin(row, start, col int) bool {
+	pos := row*t.cols + col
+	text := t.objects[pos*2+1].(*canvas.Text)
+	if len(text.Text) != 1 || !strings.ContainsAny(text.Text, ligatureSymbols) || t.cellBlinks[pos] {
+		return false
+	}
+	first := t.objects[(row*t.cols+start)*2+1].(*canvas.Text)
+	return text.Color == first.Color
+}
+
+// addLigatureRun draws the cells from start up to end as one piece of text, blanking the cells.
+// If the font would draw the run further than ligatureTolerance off those cells then the cells are left
+// to draw the text, so that long runs cannot drift off the grid.
+func (t *termGridRenderer) addLigatureRun(row, start, end int) {
+	var str strings.Builder
+	first := t.objects[(row*t.cols+start)*2+1].(*canvas.Text)
+	for col := start; col < end; col++ {
+		str.WriteString(t.objects[(row*t.cols+col)*2+1].(*canvas.Text).Text)
+	}
+	// the cells draw each glyph from the start of its cell, so the run should end where the last glyph would
+	style := fyne.TextStyle{Monospace: true}
+	width := fyne.MeasureText(str.String(), first.TextSize, style).Width
+	last := t.cellSize.Width*float32(end-start-1) + fyne.MeasureText(first.Text, first.TextSize, style).Width
+	if math.Abs(float64(width-last)) > ligatureTolerance {
+		return
+	}
+	for col := start; col < end; col++ {
+		text := t.objects[(row*t.cols+col)*2+1].(*canvas.Text)
+		text.Text = " "
+		t.refresh(text)
+	}
+
+	run := canvas.NewText(str.String(), first.Color)
+	run.TextStyle = style
+	run.TextSize = first.TextSize
+	run.Move(first.Position())
+	t.ligatures[row] = append(t.ligatures[row], ligatureRun{text: run, row: row, col: start})
+	t.ligatureLayer.Objects = append(t.ligatureLayer.Objects, run)
+}
+
+func containsRun(runs []ligatureRun, o fyne.CanvasObject) bool {
+	for _, r := range runs {
+		if r.text == o {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshBlinkingCells redraws only the cells that blink, as the blink timer toggles.
+func (t *termGridRenderer) refreshBlinkingCells() {
+	if t.text.ShowLineNumbers || t.text.ShowWhitespace || t.cols == 0 {
+		t.refreshGrid() // cells do not line up with the content
+		return
+	}
+
+	for pos, blinks := range t.cellBlinks {
+		if !blinks {
+			continue
+		}
+		row, col := pos/t.cols, pos%t.cols
+		if row < len(t.text.Rows) && col < len(t.text.Rows[row].Cells) {
+			cell := t.text.Rows[row].Cells[col]
+			t.setCellRune(cell.Rune, pos, cell.Style)
+		} else {
+			t.setCellRune(' ', pos, defaultStyle)
+		}
+	}
+	t.updateBlinkTimer()
+}
+
+// setCellBlinks records whether the cell at pos is drawn with blinking text.
+func (t *termGridRenderer) setCellBlinks(pos int, blinks bool) {
+	if pos >= len(t.cellBlinks) || t.cellBlinks[pos] == blinks {
+		return
+	}
+
+	t.cellBlinks[pos] = blinks
+	if blinks {
+		t.blinkCount++
+	} else {
+		t.blinkCount--
+	}
+}
+
+// updateBlinkTimer starts the blink timer when any cell blinks, and stops it when none do.
+func (t *termGridRenderer) updateBlinkTimer() {
 	switch {
-	case t.shouldBlink && t.tickerCancel == nil:
+	case t.blinkCount > 0 && t.tickerCancel == nil:
 		t.runBlink()
-	case !t.shouldBlink && t.tickerCancel != nil:
+	case t.blinkCount == 0 && t.tickerCancel != nil:
 		t.tickerCancel()
 		t.tickerCancel = nil
 	}
@@ -204,7 +680,7 @@ func (t *termGridRenderer) runBlink() {
 			case <-ticker.C:
 				t.SetBlink(blinking)
 				blinking = !blinking
-				t.refreshGrid()
+				t.refreshBlinkingCells()
 			}
 		}
 	}()
@@ -240,9 +716,10 @@ func (t *termGridRenderer) Layout(size fyne.Size) {
 
 	i := 0
 	cellPos := fyne.NewPos(0, 0)
+	textOffset := fyne.NewPos(0, t.text.LineSpacing/2) // keep text centred in taller or shorter rows
 	for y := 0; y < t.rows; y++ {
 		for x := 0; x < t.cols; x++ {
-			t.objects[i*2+1].Move(cellPos)
+			t.objects[i*2+1].Move(cellPos.Add(textOffset))
 
 			t.objects[i*2].Resize(t.cellSize)
 			t.objects[i*2].Move(cellPos)
@@ -253,6 +730,30 @@ func (t *termGridRenderer) Layout(size fyne.Size) {
 		cellPos.X = 0
 		cellPos.Y += t.cellSize.Height
 	}
+
+	t.ligatureLock.Lock()
+	for _, runs := range t.ligatures {
+		for _, r := range runs {
+			if pos := r.row*t.cols + r.col; r.col < t.cols && pos*2+1 < len(t.objects) {
+				r.text.Move(t.objects[pos*2+1].Position())
+			}
+		}
+	}
+	t.ligatureLock.Unlock()
+
+	t.fallbackLock.Lock()
+	for pos, obj := range t.fallbackCells {
+		obj.Resize(t.cellSize)
+		obj.Move(t.cellPosition(pos))
+	}
+	t.fallbackLock.Unlock()
+
+	t.decorationLock.Lock()
+	for pos, line := range t.overlines {
+		line.Resize(fyne.NewSize(t.cellSize.Width, overlineWidth))
+		line.Move(t.cellPosition(pos))
+	}
+	t.decorationLock.Unlock()
 }
 
 func (t *termGridRenderer) MinSize() fyne.Size {
@@ -272,9 +773,24 @@ func (t *termGridRenderer) Refresh() {
 
 	// theme could change text size
 	t.updateCellSize()
-
-	widget.TextGridStyleWhitespace = &widget.CustomTextGridStyle{FGColor: theme.DisabledColor()}
 	t.updateGridSize(t.text.Size())
+
+	fontsChanged := t.updateFallbackFonts()
+	dirty := t.text.takeDirtyRows()
+	state := gridState{
+		cellSize: t.cellSize, cols: t.cols, rows: t.rows,
+		fg: theme.ForegroundColor(), bg: theme.BackgroundColor(), defaultBG: t.text.DefaultBackground,
+		forcedFG: t.text.ForcedForeground, forcedBG: t.text.ForcedBackground,
+		inverted: t.text.Inverted, blinkDisabled: t.text.BlinkDisabled,
+		lineNumbers: t.text.ShowLineNumbers, showWhitespace: t.text.ShowWhitespace,
+		ligatures: t.text.Ligatures,
+	}
+	if t.drawn != nil && *t.drawn == state && !state.lineNumbers && !state.showWhitespace && !fontsChanged {
+		t.refreshRows(dirty)
+		return
+	}
+
+	t.drawn = &state
 	t.refreshGrid()
 }
 
@@ -282,7 +798,7 @@ func (t *termGridRenderer) ApplyTheme() {
 }
 
 func (t *termGridRenderer) Objects() []fyne.CanvasObject {
-	return t.objects
+	return t.layers
 }
 
 func (t *termGridRenderer) Destroy() {
@@ -304,13 +820,7 @@ func (t *termGridRenderer) refresh(obj fyne.CanvasObject) {
 }
 
 func (t *termGridRenderer) updateCellSize() {
-	size := fyne.MeasureText("M", theme.TextSize(), fyne.TextStyle{Monospace: true})
-
-	// round it for seamless background
-	size.Width = float32(math.Round(float64((size.Width))))
-	size.Height = float32(math.Round(float64((size.Height))))
-
-	t.cellSize = size
+	t.cellSize = t.text.CellSize()
 }
 
 func (t *termGridRenderer) SetBlink(b bool) {
@@ -0,0 +1,320 @@
+package widget
+
+import (
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestTermGrid_BlinkDisabled(t *testing.T) {
+	test.NewApp()
+	fg := &color.RGBA{R: 255, A: 255}
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A', Style: NewTermTextGridStyle(fg, nil, 0x55, true)}}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+	if r.tickerCancel == nil {
+		t.Fatal("expected blinking text to start the blink timer")
+	}
+
+	grid.BlinkDisabled = true
+	r.SetBlink(true)
+	r.Refresh()
+	if r.tickerCancel != nil {
+		t.Error("expected the blink timer to stop")
+	}
+	if text := r.objects[1].(*canvas.Text); text.Color != fg {
+		t.Errorf("expected steady text colour %v, got %v", fg, text.Color)
+	}
+}
+
+func TestTermGrid_BlinkCount(t *testing.T) {
+	test.NewApp()
+	fg := &color.RGBA{R: 255, A: 255}
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A'}, {Rune: 'B', Style: NewTermTextGridStyle(fg, nil, 0x55, true)}}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+	if r.blinkCount != 1 || !r.cellBlinks[1] {
+		t.Fatalf("expected one blinking cell, got %d", r.blinkCount)
+	}
+
+	r.SetBlink(true)
+	r.refreshBlinkingCells()
+	if text := r.objects[3].(*canvas.Text); text.Color == fg {
+		t.Error("expected the blinking cell to be hidden")
+	}
+
+	grid.SetCell(0, 1, widget.TextGridCell{Rune: 'C'})
+	r.Refresh()
+	if r.blinkCount != 0 {
+		t.Errorf("expected no blinking cells, got %d", r.blinkCount)
+	}
+	if r.tickerCancel != nil {
+		t.Error("expected the blink timer to stop without a full refresh")
+	}
+}
+
+func TestTermGrid_RefreshDirtyRows(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A'}}},
+		{Cells: []widget.TextGridCell{{Rune: 'B'}}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+
+	grid.SetCell(1, 0, widget.TextGridCell{Rune: 'C'})
+	grid.Rows[0].Cells[0].Rune = 'D' // not marked, so not redrawn
+	r.Refresh()
+	if text := r.objects[r.cols*2+1].(*canvas.Text); text.Text != "C" {
+		t.Errorf("expected dirty row to be redrawn, got %q", text.Text)
+	}
+	if text := r.objects[1].(*canvas.Text); text.Text != "A" {
+		t.Errorf("expected clean row to be left alone, got %q", text.Text)
+	}
+
+	grid.Inverted = true
+	r.Refresh()
+	if text := r.objects[1].(*canvas.Text); text.Text != "D" {
+		t.Errorf("expected a full redraw when the grid state changes, got %q", text.Text)
+	}
+}
+
+func TestTermGrid_DefaultBackground(t *testing.T) {
+	test.NewApp()
+	bg := &color.RGBA{B: 255, A: 255}
+	red := &color.RGBA{R: 255, A: 255}
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A'}, {Rune: 'B', Style: &widget.CustomTextGridStyle{BGColor: red}}}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+	if rect := r.objects[0].(*canvas.Rectangle); rect.FillColor != color.Transparent {
+		t.Errorf("expected a transparent background, got %v", rect.FillColor)
+	}
+
+	grid.DefaultBackground = bg
+	r.Refresh()
+	if rect := r.objects[0].(*canvas.Rectangle); rect.FillColor != bg {
+		t.Errorf("expected existing cells to use the default background, got %v", rect.FillColor)
+	}
+	if rect := r.objects[2].(*canvas.Rectangle); rect.FillColor != red {
+		t.Errorf("expected an explicit background to be kept, got %v", rect.FillColor)
+	}
+	if rect := r.objects[len(r.objects)-2].(*canvas.Rectangle); rect.FillColor != bg {
+		t.Errorf("expected blank cells to use the default background, got %v", rect.FillColor)
+	}
+}
+
+func TestTermGrid_ForcedColors(t *testing.T) {
+	test.NewApp()
+	fg := &color.RGBA{R: 255, G: 255, A: 255}
+	bg := &color.RGBA{A: 255}
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{
+			{Rune: 'A', Style: &widget.CustomTextGridStyle{FGColor: &color.RGBA{R: 255, A: 255}, BGColor: &color.RGBA{B: 255, A: 255}}},
+			{Rune: 'B', Style: &TermTextGridStyle{Highlighted: true}},
+		}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+
+	grid.ForcedForeground, grid.ForcedBackground = fg, bg
+	r.Refresh()
+	if text := r.objects[1].(*canvas.Text); text.Color != fg {
+		t.Errorf("expected forced text colour, got %v", text.Color)
+	}
+	if rect := r.objects[0].(*canvas.Rectangle); rect.FillColor != bg {
+		t.Errorf("expected forced background colour, got %v", rect.FillColor)
+	}
+	if text := r.objects[3].(*canvas.Text); text.Color != bg {
+		t.Errorf("expected highlighted text to swap the forced colours, got %v", text.Color)
+	}
+
+	grid.ForcedForeground, grid.ForcedBackground = nil, nil
+	r.Refresh()
+	if rect := r.objects[0].(*canvas.Rectangle); rect.FillColor == bg {
+		t.Error("expected the cell colours to return")
+	}
+}
+
+func TestTermGrid_FallbackFonts(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A'}, {Rune: '😀'}}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+	if len(r.fallbackCells) != 0 {
+		t.Error("expected no fallback glyphs without fallback fonts")
+	}
+
+	grid.FallbackFonts = []fyne.Resource{theme.DefaultEmojiFont()}
+	r.Refresh()
+	if _, ok := r.fallbackCells[1]; !ok || len(r.fallbackCells) != 1 {
+		t.Fatal("expected the missing glyph to use the fallback font")
+	}
+	if text := r.objects[3].(*canvas.Text); text.Text != " " {
+		t.Errorf("expected the text cell to be blank, got %q", text.Text)
+	}
+	if text := r.objects[1].(*canvas.Text); text.Text != "A" {
+		t.Errorf("expected the monospace font for ASCII, got %q", text.Text)
+	}
+
+	grid.SetCell(0, 1, widget.TextGridCell{Rune: 'B'})
+	r.Refresh()
+	if len(r.fallbackCells) != 0 || len(r.fallbackLayer.Objects) != 0 {
+		t.Error("expected the fallback glyph to be removed")
+	}
+}
+
+func TestTermGrid_Ligatures(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'a'}, {Rune: '-'}, {Rune: '>'}, {Rune: 'b'}, {Rune: '!'}}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+	if len(r.ligatureLayer.Objects) != 0 {
+		t.Error("expected no joined text by default")
+	}
+
+	grid.Ligatures = true
+	r.Refresh()
+	if len(r.ligatureLayer.Objects) != 1 {
+		t.Fatalf("expected one joined run, got %d", len(r.ligatureLayer.Objects))
+	}
+	run := r.ligatureLayer.Objects[0].(*canvas.Text)
+	if run.Text != "->" || run.Position() != r.objects[3].Position() {
+		t.Errorf("expected \"->\" over the second cell, got %q at %v", run.Text, run.Position())
+	}
+	if text := r.objects[3].(*canvas.Text); text.Text != " " {
+		t.Errorf("expected the joined cell to be blank, got %q", text.Text)
+	}
+
+	r.cellSize.Width += 2
+	r.ligatureLock.Lock()
+	r.updateLigatures(0)
+	r.ligatureLock.Unlock()
+	if len(r.ligatureLayer.Objects) != 0 {
+		t.Error("expected no joined run when the text would not fit its cells")
+	}
+	r.Refresh()
+
+	grid.SetCell(0, 2, widget.TextGridCell{Rune: 'c'})
+	r.Refresh()
+	if len(r.ligatureLayer.Objects) != 0 {
+		t.Error("expected the run to be removed when the row changes")
+	}
+	if text := r.objects[3].(*canvas.Text); text.Text != "-" {
+		t.Errorf("expected the cell text to return, got %q", text.Text)
+	}
+}
+
+func newBenchmarkGrid() (*TermGrid, *termGridRenderer) {
+	test.NewApp()
+	grid := NewTermGrid()
+	for i := 0; i < 50; i++ {
+		row := widget.TextGridRow{}
+		for j := 0; j < 80; j++ {
+			row.Cells = append(row.Cells, widget.TextGridCell{Rune: rune('a' + j%26)})
+		}
+		grid.Rows = append(grid.Rows, row)
+	}
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	grid.Resize(fyne.NewSize(r.cellSize.Width*80, r.cellSize.Height*50))
+	r.Refresh()
+	return grid, r
+}
+
+func BenchmarkTermGrid_RefreshFull(b *testing.B) {
+	_, r := newBenchmarkGrid()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.refreshGrid()
+	}
+}
+
+func BenchmarkTermGrid_RefreshIncremental(b *testing.B) {
+	grid, r := newBenchmarkGrid()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.MarkRowDirty(25) // the row the cursor moved on
+		r.Refresh()
+	}
+}
+
+func BenchmarkTermGrid_BlinkTick(b *testing.B) {
+	_, r := newBenchmarkGrid() // 80x50 with no blinking cells
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.SetBlink(i%2 == 0)
+		r.refreshBlinkingCells()
+	}
+}
+
+func TestTermGrid_Overline(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	overline := NewTermTextGridStyle(nil, nil, 0x55, false)
+	overline.(*TermTextGridStyle).Overline = true
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A', Style: overline}, {Rune: 'B'}}},
+	}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+	if len(r.decorationLayer.Objects) != 1 {
+		t.Fatalf("expected one overline, got %d", len(r.decorationLayer.Objects))
+	}
+	if line := r.decorationLayer.Objects[0]; line.Position() != fyne.NewPos(0, 0) ||
+		line.Size().Width != r.cellSize.Width {
+		t.Errorf("expected the overline along the top of the first cell, got %v %v", line.Position(), line.Size())
+	}
+
+	grid.SetCell(0, 0, widget.TextGridCell{Rune: 'A'})
+	r.Refresh()
+	if len(r.decorationLayer.Objects) != 0 {
+		t.Error("expected the overline to be removed")
+	}
+}
+
+func TestTermGrid_Concealed(t *testing.T) {
+	test.NewApp()
+	bg := &color.RGBA{B: 255, A: 255}
+	concealed := NewTermTextGridStyle(nil, bg, 0x55, false)
+	concealed.(*TermTextGridStyle).Concealed = true
+	grid := NewTermGrid()
+	grid.Rows = []widget.TextGridRow{{Cells: []widget.TextGridCell{{Rune: 'A', Style: concealed}}}}
+	grid.Resize(fyne.NewSize(100, 100))
+	r := test.WidgetRenderer(grid).(*termGridRenderer)
+	r.Refresh()
+	if text := r.objects[1].(*canvas.Text); text.Text != "A" || text.Color != bg {
+		t.Errorf("expected the text to be drawn in the background colour, got %q %v", text.Text, text.Color)
+	}
+}
@@ -26,6 +26,7 @@ func HighlightRange(t *TermGrid, blockMode bool, startRow, startCol, endRow, end
 	}
 
 	forRange(t, blockMode, startRow, startCol, endRow, endCol, applyHighlight, nil)
+	t.MarkRowsDirty(startRow, endRow)
 }
 
 // ClearHighlightRange disables the highlight style for the given range
@@ -37,6 +38,7 @@ func ClearHighlightRange(t *TermGrid, blockMode bool, startRow, startCol, endRow
 		}
 	}
 	forRange(t, blockMode, startRow, startCol, endRow, endCol, clearHighlight, nil)
+	t.MarkRowsDirty(startRow, endRow)
 }
 
 // GetTextRange retrieves a text range from the TextGrid. It collects the text
@@ -58,10 +60,22 @@ func ClearHighlightRange(t *TermGrid, blockMode bool, startRow, startCol, endRow
 //   - string: The text content within the specified range as a string.
 func GetTextRange(t *TermGrid, blockMode bool, startRow, startCol, endRow, endCol int) string {
 	var result []rune
+	blanks := 0 // blank cells are only included if they are followed by content on the same row
 
 	forRange(t, blockMode, startRow, startCol, endRow, endCol, func(cell *widget.TextGridCell) {
+		if cell.Rune == WideCharPadding {
+			return
+		}
+		if isBlank(*cell) {
+			blanks++
+			return
+		}
+		for ; blanks > 0; blanks-- {
+			result = append(result, ' ')
+		}
 		result = append(result, cell.Rune)
 	}, func(row *widget.TextGridRow) {
+		blanks = 0
 		result = append(result, '\n')
 	})
 
@@ -172,6 +186,12 @@ type TermTextGridStyle struct {
 	InvertedBackgroundColor color.Color
 	Highlighted             bool
 	BlinkEnabled            bool
+	// Protected cells are not changed by selective erase (DECSED and DECSEL).
+	Protected bool
+	// Overline draws a line along the top of the cell.
+	Overline bool
+	// Concealed cells draw their text in the background colour.
+	Concealed bool
 }
 
 // TextColor returns the color of the text, depending on whether it is highlighted.
@@ -0,0 +1,237 @@
+package terminal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// kittyTransfer collects the chunks of a kitty graphics command sent with m=1.
+type kittyTransfer struct {
+	controls map[string]string
+	data     strings.Builder
+}
+
+// handleKittyGraphics processes a kitty graphics protocol command, the content of an APC G sequence.
+// Images can be sent directly (optionally compressed and in chunks) as raw RGB(A) or PNG data,
+// displayed at the cursor, and deleted.
+func (s *Screen) handleKittyGraphics(code string) {
+	parts := strings.SplitN(code, ";", 2)
+	controls := parseKittyControls(parts[0])
+	payload := ""
+	if len(parts) == 2 {
+		payload = parts[1]
+	}
+
+	if s.kittyTransfer != nil {
+		if s.kittyTransfer.data.Len()+len(payload) > maxImageDataLength {
+			controls = s.kittyTransfer.controls
+			s.kittyTransfer = nil
+			s.replyKitty(controls, "EINVAL:image data is too long")
+			return
+		}
+		s.kittyTransfer.data.WriteString(payload)
+		if controls["m"] == "1" {
+			return
+		}
+		controls = s.kittyTransfer.controls
+		payload = s.kittyTransfer.data.String()
+		s.kittyTransfer = nil
+	} else if controls["m"] == "1" {
+		s.kittyTransfer = &kittyTransfer{controls: controls}
+		s.kittyTransfer.data.WriteString(payload)
+		return
+	}
+
+	action := controls["a"]
+	if action == "" {
+		action = "t"
+	}
+	switch action {
+	case "t", "T", "q":
+		img, err := decodeKittyImage(controls, payload)
+		if err != nil {
+			s.replyKitty(controls, "EINVAL:"+err.Error())
+			return
+		}
+		if action == "q" {
+			s.replyKitty(controls, "OK")
+			return
+		}
+
+		id := kittyNumber(controls, "i")
+		if id != 0 {
+			if s.kittyImages == nil {
+				s.kittyImages = make(map[uint32]image.Image)
+			}
+			s.kittyImages[id] = img
+		}
+		if action == "T" {
+			s.placeKittyImage(img, id, controls)
+		}
+		s.replyKitty(controls, "OK")
+	case "p":
+		id := kittyNumber(controls, "i")
+		img, ok := s.kittyImages[id]
+		if !ok {
+			s.replyKitty(controls, "ENOENT:image not found")
+			return
+		}
+		s.placeKittyImage(img, id, controls)
+		s.replyKitty(controls, "OK")
+	case "d":
+		s.deleteKittyImages(controls)
+	}
+}
+
+// placeKittyImage shows an image at the cursor using the placement keys of a kitty graphics command.
+func (s *Screen) placeKittyImage(img image.Image, id uint32, controls map[string]string) {
+	if w, h := kittyNumber(controls, "w"), kittyNumber(controls, "h"); w > 0 || h > 0 {
+		if sub, ok := img.(interface {
+			SubImage(image.Rectangle) image.Image
+		}); ok {
+			x, y := int(kittyNumber(controls, "x")), int(kittyNumber(controls, "y"))
+			b := img.Bounds()
+			if w == 0 {
+				w = uint32(b.Dx() - x)
+			}
+			if h == 0 {
+				h = uint32(b.Dy() - y)
+			}
+			img = sub.SubImage(image.Rect(x, y, x+int(w), y+int(h)).Add(b.Min))
+		}
+	}
+
+	cols, rows := controls["c"], controls["r"]
+	placed := s.placeImage(img, cols, rows, cols == "" || rows == "", controls["C"] != "1")
+	if placed == nil {
+		return
+	}
+	placed.id = id
+	placed.z, _ = strconv.Atoi(controls["z"])
+}
+
+// deleteKittyImages removes placements as requested by a kitty graphics delete command.
+// Upper case selectors also free the image data.
+func (s *Screen) deleteKittyImages(controls map[string]string) {
+	sel := controls["d"]
+	if sel == "" {
+		sel = "a"
+	}
+	free := strings.ToUpper(sel) == sel
+	id := kittyNumber(controls, "i")
+
+	kept := s.images[:0]
+	for _, img := range s.images {
+		switch strings.ToLower(sel) {
+		case "a":
+			if img.id != 0 && free {
+				delete(s.kittyImages, img.id)
+			}
+			continue
+		case "i":
+			if img.id == id {
+				continue
+			}
+		}
+		kept = append(kept, img)
+	}
+	s.images = kept
+	if strings.ToLower(sel) == "i" && free {
+		delete(s.kittyImages, id)
+	}
+}
+
+// replyKitty sends the response to a kitty graphics command, unless it had no image id or asked to be quiet.
+func (s *Screen) replyKitty(controls map[string]string, msg string) {
+	id := kittyNumber(controls, "i")
+	quiet := controls["q"]
+	if id == 0 || quiet == "2" || (quiet == "1" && msg == "OK") {
+		return
+	}
+	s.reply([]byte(fmt.Sprintf("\x1b_Gi=%d;%s\x1b\\", id, msg)))
+}
+
+func decodeKittyImage(controls map[string]string, payload string) (image.Image, error) {
+	if medium := controls["t"]; medium != "" && medium != "d" {
+		return nil, fmt.Errorf("unsupported transmission medium %s", medium)
+	}
+	if len(payload) > maxImageDataLength {
+		return nil, errors.New("image data is too long")
+	}
+
+	format := controls["f"]
+	bpp, size := 0, maxImageDataLength
+	switch format {
+	case "100":
+	case "", "32":
+		bpp = 4
+	case "24":
+		bpp = 3
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+	w, h := int(kittyNumber(controls, "s")), int(kittyNumber(controls, "v"))
+	if bpp != 0 {
+		if w <= 0 || h <= 0 || w > maxImageDimension || h > maxImageDimension {
+			return nil, fmt.Errorf("invalid image size %dx%d", w, h)
+		}
+		size = w * h * bpp // at most 4 * maxImageDimension squared, so this cannot overflow
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+	if controls["o"] == "z" {
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(io.LimitReader(r, int64(size)+1)); err != nil {
+			return nil, err
+		}
+		if len(data) > size {
+			return nil, errors.New("decompressed image data is too long")
+		}
+	}
+
+	if bpp == 0 {
+		return decodeImage(data)
+	}
+	if len(data) < size {
+		return nil, fmt.Errorf("image data does not match size %dx%d", w, h)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if bpp == 4 {
+		copy(img.Pix, data)
+		return img, nil
+	}
+	for i := 0; i < w*h; i++ {
+		copy(img.Pix[i*4:], data[i*3:i*3+3])
+		img.Pix[i*4+3] = 0xff
+	}
+	return img, nil
+}
+
+func parseKittyControls(s string) map[string]string {
+	controls := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 {
+			controls[kv[0]] = kv[1]
+		}
+	}
+	return controls
+}
+
+func kittyNumber(controls map[string]string, key string) uint32 {
+	n, _ := strconv.ParseUint(controls[key], 10, 32)
+	return uint32(n)
+}
@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func kittyTestTerminal() (*Terminal, *bytes.Buffer) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+	in := &bytes.Buffer{}
+	term.in = NopCloser(in)
+	return term, in
+}
+
+func TestKittyGraphics_TransmitAndDisplay(t *testing.T) {
+	term, in := kittyTestTerminal()
+	data := base64.StdEncoding.EncodeToString([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	term.handleOutput([]byte("\x1b_Ga=T,f=32,s=2,v=1,c=2,r=1,i=7;" + data + "\x1b\\"))
+	assert.Equal(t, 1, len(term.screen.images))
+	img := term.screen.images[0]
+	assert.Equal(t, uint32(7), img.id)
+	assert.Equal(t, 2, img.width)
+	assert.Equal(t, 1, img.height)
+	assert.Equal(t, 2, term.screen.cursorCol)
+	assert.Equal(t, "\x1b_Gi=7;OK\x1b\\", in.String())
+
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, img.img.(*image.NRGBA).Pix)
+}
+
+func TestKittyGraphics_Chunked(t *testing.T) {
+	term, _ := kittyTestTerminal()
+	data := base64.StdEncoding.EncodeToString([]byte{1, 2, 3, 4, 5, 6})
+
+	term.handleOutput([]byte("\x1b_Ga=T,f=24,s=2,v=1,c=2,r=1,C=1,m=1;" + data[:4] + "\x1b\\"))
+	assert.Equal(t, 0, len(term.screen.images))
+	term.handleOutput([]byte("\x1b_Gm=0;" + data[4:] + "\x1b\\"))
+	assert.Equal(t, 1, len(term.screen.images))
+	assert.Equal(t, 0, term.screen.cursorCol) // C=1 leaves the cursor in place
+
+	assert.Equal(t, []byte{1, 2, 3, 0xff, 4, 5, 6, 0xff}, term.screen.images[0].img.(*image.NRGBA).Pix)
+}
+
+func TestKittyGraphics_Limits(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString([]byte{1, 2, 3, 4})
+	for _, size := range []string{"s=0,v=1", "s=1,v=0", "s=10001,v=1", "s=1,v=10001", "s=4294967295,v=4294967295"} {
+		_, err := decodeKittyImage(parseKittyControls("f=32,"+size), data)
+		assert.NotNil(t, err, size)
+	}
+
+	zipped := &bytes.Buffer{}
+	w := zlib.NewWriter(zipped)
+	_, _ = w.Write(make([]byte, 1024*1024))
+	_ = w.Close()
+	_, err := decodeKittyImage(parseKittyControls("f=32,s=1,v=1,o=z"),
+		base64.StdEncoding.EncodeToString(zipped.Bytes()))
+	assert.NotNil(t, err) // more data than the size needs is not decompressed
+}
+
+func TestKittyGraphics_PlaceAndDelete(t *testing.T) {
+	term, in := kittyTestTerminal()
+	data := base64.StdEncoding.EncodeToString([]byte{1, 2, 3, 4})
+
+	term.handleOutput([]byte("\x1b_Gi=3,s=1,v=1,q=1;" + data + "\x1b\\"))
+	assert.Equal(t, 0, len(term.screen.images))
+	assert.Equal(t, "", in.String())
+
+	term.handleOutput([]byte("\x1b_Ga=p,i=3,c=1,r=1,z=-1\x1b\\"))
+	assert.Equal(t, 1, len(term.screen.images))
+	assert.Equal(t, -1, term.screen.images[0].z)
+
+	term.handleOutput([]byte("\x1b_Ga=d,d=I,i=3\x1b\\"))
+	assert.Equal(t, 0, len(term.screen.images))
+	in.Reset()
+	term.handleOutput([]byte("\x1b_Ga=p,i=3\x1b\\"))
+	assert.Equal(t, "\x1b_Gi=3;ENOENT:image not found\x1b\\", in.String())
+}
@@ -0,0 +1,166 @@
+package terminal
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+
+	widget2 "github.com/fyne-io/terminal/internal/widget"
+)
+
+var linkPattern = regexp.MustCompile(`(https?|file)://[^\s]+`)
+
+// link is a URL found in the terminal content, start and end are the first and last cells it covers.
+type link struct {
+	url        *url.URL
+	start, end position
+}
+
+func (l *link) contains(p position) bool {
+	if p.Row < l.start.Row || p.Row > l.end.Row {
+		return false
+	}
+	if p.Row == l.start.Row && p.Col < l.start.Col {
+		return false
+	}
+	return p.Row != l.end.Row || p.Col <= l.end.Col
+}
+
+// SetURLDetectionEnabled sets whether URLs in the terminal output are underlined when the mouse is over them
+// and opened with Ctrl+click (Cmd+click on macOS). The default is false.
+func (t *Terminal) SetURLDetectionEnabled(enabled bool) {
+	t.urlDetection = enabled
+	if !enabled {
+		t.setHoveredLink(nil)
+	}
+}
+
+// SetURLOpenHandler sets a function to call when a detected URL is clicked, replacing the default
+// of opening it with the application. Pass nil to restore the default.
+func (t *Terminal) SetURLOpenHandler(handler func(*url.URL)) {
+	t.urlOpenHandler = handler
+}
+
+// MouseIn is called when the mouse enters the terminal.
+func (t *Terminal) MouseIn(ev *desktop.MouseEvent) {
+	t.MouseMoved(ev)
+}
+
+// MouseMoved is called when the mouse moves over the terminal, it is used to find URLs under the pointer.
+func (t *Terminal) MouseMoved(ev *desktop.MouseEvent) {
+	if !t.urlDetection {
+		return
+	}
+
+	t.setHoveredLink(t.linkAt(t.getTermPosition(ev.Position)))
+}
+
+// MouseOut is called when the mouse leaves the terminal.
+func (t *Terminal) MouseOut() {
+	t.setHoveredLink(nil)
+}
+
+// openLinkAt opens the URL under the given mouse event if it is a Ctrl+click on a detected link.
+// It returns true if a link was opened.
+func (t *Terminal) openLinkAt(ev *desktop.MouseEvent) bool {
+	if !t.urlDetection || ev.Button != desktop.MouseButtonPrimary ||
+		ev.Modifier&fyne.KeyModifierShortcutDefault == 0 {
+		return false
+	}
+	l := t.linkAt(t.getTermPosition(ev.Position))
+	if l == nil {
+		return false
+	}
+
+	if t.urlOpenHandler != nil {
+		t.urlOpenHandler(l.url)
+	} else if a := fyne.CurrentApp(); a != nil {
+		_ = a.OpenURL(l.url)
+	}
+	return true
+}
+
+func (t *Terminal) linkAt(p position) *link {
+	for _, l := range t.detectLinks() {
+		if l.contains(p) {
+			return l
+		}
+	}
+	return nil
+}
+
+// detectLinks finds the URLs in the visible rows. A row that is filled to the last column is joined
+// with the next, so that URLs which wrap across lines are found.
+func (t *Terminal) detectLinks() []*link {
+	var text strings.Builder
+	var cells []position // the cell for each byte of text
+	for r, row := range t.grid.Rows {
+		last := len(row.Cells) - 1
+		for c, cell := range row.Cells {
+			ch := cell.Rune
+			if ch == widget2.WideCharPadding {
+				continue
+			}
+			if ch == 0 {
+				ch = ' '
+			}
+			text.WriteRune(ch)
+			for i := 0; i < utf8.RuneLen(ch); i++ {
+				cells = append(cells, position{Col: c + 1, Row: r + 1})
+			}
+		}
+		if last < 0 || row.Cells[last].Rune == ' ' || row.Cells[last].Rune == 0 {
+			text.WriteByte('\n')
+			cells = append(cells, position{})
+		}
+	}
+
+	var links []*link
+	for _, match := range linkPattern.FindAllStringIndex(text.String(), -1) {
+		raw := strings.TrimRight(text.String()[match[0]:match[1]], ".,;:!?'\")]>")
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		links = append(links, &link{url: u, start: cells[match[0]], end: cells[match[0]+len(raw)-1]})
+	}
+	return links
+}
+
+// setHoveredLink underlines the given link, or removes the underline if it is nil.
+func (t *Terminal) setHoveredLink(l *link) {
+	if t.hoveredLink == l || (t.hoveredLink != nil && l != nil &&
+		t.hoveredLink.start == l.start && t.hoveredLink.end == l.end) {
+		return
+	}
+	t.hoveredLink = l
+	if t.linkUnderline == nil { // not yet rendered
+		return
+	}
+
+	t.linkUnderline.Objects = nil
+	if l != nil {
+		cell := t.guessCellSize()
+		for row := l.start.Row; row <= l.end.Row; row++ {
+			start, end := 1, int(t.screen.config.Columns)
+			if row == l.start.Row {
+				start = l.start.Col
+			}
+			if row == l.end.Row {
+				end = l.end.Col
+			}
+
+			line := canvas.NewRectangle(theme.ForegroundColor())
+			line.Move(fyne.NewPos(cell.Width*float32(start-1), cell.Height*float32(row)-1))
+			line.Resize(fyne.NewSize(cell.Width*float32(end-start+1), 1))
+			t.linkUnderline.Objects = append(t.linkUnderline.Objects, line)
+		}
+	}
+	t.linkUnderline.Refresh()
+}
@@ -0,0 +1,49 @@
+package terminal
+
+import (
+	"net/url"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLinks(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("see https://fy.io/x."))
+
+	links := term.detectLinks()
+	assert.Equal(t, 1, len(links))
+	assert.Equal(t, "https://fy.io/x", links[0].url.String()) // wrapped, without the full stop
+	assert.Equal(t, position{Col: 5, Row: 1}, links[0].start)
+	assert.Equal(t, position{Col: 9, Row: 2}, links[0].end)
+}
+
+func TestOpenLink(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 20
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte("go file:///tmp now"))
+
+	var opened *url.URL
+	term.SetURLOpenHandler(func(u *url.URL) {
+		opened = u
+	})
+	cell := term.guessCellSize()
+	ev := &desktop.MouseEvent{Button: desktop.MouseButtonPrimary}
+	ev.Position = fyne.NewPos(cell.Width*5.5, cell.Height/2)
+
+	assert.False(t, term.openLinkAt(ev)) // detection is off by default
+	term.SetURLDetectionEnabled(true)
+	assert.False(t, term.openLinkAt(ev)) // needs the modifier
+	ev.Modifier = fyne.KeyModifierShortcutDefault
+	assert.True(t, term.openLinkAt(ev))
+	assert.Equal(t, "file:///tmp", opened.String())
+
+	ev.Position = fyne.NewPos(cell.Width*0.5, cell.Height/2)
+	assert.False(t, term.openLinkAt(ev))
+}
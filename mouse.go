@@ -1,9 +1,55 @@
 package terminal
 
 import (
+	"fmt"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+const (
+	maxMouseLegacy = 255 - 32  // the largest position in the default mouse encoding
+	maxMouseUTF8   = 2047 - 32 // the largest position in the UTF-8 mouse encoding (1005)
 )
 
+// mouseButton returns the number that xterm uses for a mouse button, 1 to 3 from left to right,
+// or 0 if it is not one that is reported.
+func mouseButton(b desktop.MouseButton) int {
+	switch b {
+	case desktop.MouseButtonPrimary:
+		return 1
+	case desktop.MouseButtonTertiary:
+		return 2
+	case desktop.MouseButtonSecondary:
+		return 3
+	}
+	return 0
+}
+
+// mouseHandlers returns the functions that report mouse buttons to the program,
+// or nil if it has not asked for mouse tracking.
+func (t *Terminal) mouseHandlers() (down, up func(int, fyne.KeyModifier, fyne.Position)) {
+	switch t.screen.mouseTracking {
+	case mouseTrackingX10:
+		return t.handleMouseDownX10, t.handleMouseUpX10
+	case mouseTrackingNormal:
+		return t.handleMouseDownV200, t.handleMouseUpV200
+	}
+	return nil, nil
+}
+
+// reportWheel sends scrolling of the mouse wheel as presses of button 4 (up) or 5 (down), one for each line.
+func (t *Terminal) reportWheel(lines int, pos fyne.Position) {
+	down, _ := t.mouseHandlers()
+	btn := 4
+	if lines < 0 {
+		btn, lines = 5, -lines
+	}
+	for i := 0; i < lines; i++ {
+		down(btn, 0, pos)
+	}
+}
+
 func (t *Terminal) handleMouseDownV200(btn int, mods fyne.KeyModifier, pos fyne.Position) {
 	_, _ = t.Write(t.encodeMouse(btn, mods, pos))
 }
@@ -13,20 +59,73 @@ func (t *Terminal) handleMouseDownX10(btn int, _ fyne.KeyModifier, pos fyne.Posi
 }
 
 func (t *Terminal) handleMouseUpV200(btn int, mods fyne.KeyModifier, pos fyne.Position) {
-	_, _ = t.Write(t.encodeMouse(0, mods, pos))
+	_, _ = t.Write(t.encodeMouseRelease(btn, mods, pos))
 }
 
 func (t *Terminal) handleMouseUpX10(_ int, _ fyne.KeyModifier, _ fyne.Position) {
 	// no-op for X10 mode
 }
 
+// encodeMouse returns the report of a button press, or a release if the button is 0, in the encoding
+// that the program asked for. The SGR encoding (1006) is preferred, then UTF-8 (1005).
+// In the default encoding positions beyond column or row 223 cannot be sent, so they are clamped.
 func (t *Terminal) encodeMouse(button int, mods fyne.KeyModifier, pos fyne.Position) []byte {
 	p := t.getTermPosition(pos)
-	var btn byte
-	if button == 0 {
+	btn := mouseButtonCode(button, mods)
+	if t.screen.mouseSGR {
+		return encodeMouseSGR(btn, p, button == 0)
+	}
+
+	out := []byte{asciiEscape, '[', 'M'}
+	if t.screen.mouseUTF8 {
+		for _, v := range []int{btn, p.Col, p.Row} {
+			if v > maxMouseUTF8 {
+				v = maxMouseUTF8
+			}
+			out = append(out, string(rune(32+v))...)
+		}
+		return out
+	}
+	return append(out, 32+byte(btn), legacyMouseCoord(p.Col), legacyMouseCoord(p.Row))
+}
+
+// encodeMouseRelease returns the report of a button being released.
+// Only the SGR encoding says which button it was, the others report a release of any button.
+func (t *Terminal) encodeMouseRelease(button int, mods fyne.KeyModifier, pos fyne.Position) []byte {
+	if t.screen.mouseSGR {
+		return encodeMouseSGR(mouseButtonCode(button, mods), t.getTermPosition(pos), true)
+	}
+	return t.encodeMouse(0, mods, pos)
+}
+
+func encodeMouseSGR(btn int, p position, release bool) []byte {
+	final := 'M'
+	if release {
+		final = 'm'
+	}
+	return []byte(fmt.Sprintf("\x1b[<%d;%d;%d%c", btn, p.Col, p.Row, final))
+}
+
+// legacyMouseCoord returns the byte for a column or row in the default mouse encoding,
+// which can only represent positions up to 223.
+func legacyMouseCoord(v int) byte {
+	if v > maxMouseLegacy {
+		v = maxMouseLegacy
+	}
+	return 32 + byte(v)
+}
+
+// mouseButtonCode returns the number that reports a button, 0 for a release and 4 or 5 for the wheel,
+// with the modifiers held.
+func mouseButtonCode(button int, mods fyne.KeyModifier) int {
+	var btn int
+	switch {
+	case button == 0:
 		btn = 3
-	} else {
-		btn = byte(button) - 1
+	case button >= 4:
+		btn = 64 + button - 4 // the wheel
+	default:
+		btn = button - 1
 	}
 
 	if mods&fyne.KeyModifierShift != 0 {
@@ -38,6 +137,5 @@ func (t *Terminal) encodeMouse(button int, mods fyne.KeyModifier, pos fyne.Posit
 	if mods&fyne.KeyModifierControl != 0 {
 		btn += 16
 	}
-
-	return []byte{asciiEscape, '[', 'M', 32 + btn, 32 + byte(p.Col), 32 + byte(p.Row)}
+	return btn
 }
@@ -1,9 +1,11 @@
 package terminal
 
 import (
+	"bytes"
 	"testing"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,3 +27,49 @@ func TestEncodeMouse_Mods(t *testing.T) {
 	assert.Equal(t, "\x1b[M5!!", string(term.encodeMouse(2,
 		fyne.KeyModifierShift|fyne.KeyModifierControl, fyne.NewPos(4, 4))))
 }
+
+func TestEncodeMouse_LargePositions(t *testing.T) {
+	term := New()
+	cell := term.guessCellSize()
+	pos := fyne.NewPos(cell.Width*299+1, 1) // column 300, row 1
+
+	assert.Equal(t, []byte{asciiEscape, '[', 'M', ' ', 255, '!'}, term.encodeMouse(1, 0, pos))
+
+	term.screen.handleEscape("?1005h")
+	assert.Equal(t, "\x1b[M Ō!", string(term.encodeMouse(1, 0, pos)))
+
+	term.screen.handleEscape("?1006h")
+	assert.Equal(t, "\x1b[<0;300;1M", string(term.encodeMouse(1, 0, pos)))
+	assert.Equal(t, "\x1b[<1;300;1m", string(term.encodeMouseRelease(2, 0, pos)))
+	term.screen.handleEscape("?1006l")
+	term.screen.handleEscape("?1005l")
+	assert.Equal(t, "\x1b[M#!!", string(term.encodeMouseRelease(2, 0, fyne.NewPos(4, 4))))
+}
+
+func TestTerminal_MouseButtons(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.screen.handleEscape("?1000h")
+
+	pos := fyne.NewPos(4, 4)
+	term.MouseDown(&desktop.MouseEvent{Button: desktop.MouseButtonTertiary, PointEvent: fyne.PointEvent{Position: pos}})
+	term.MouseDown(&desktop.MouseEvent{Button: desktop.MouseButtonSecondary, PointEvent: fyne.PointEvent{Position: pos}})
+	assert.Equal(t, "\x1b[M!!!\x1b[M\"!!", inBuffer.String())
+}
+
+func TestTerminal_ScrolledReportsWheel(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.screen.handleEscape("?1000h")
+	term.screen.handleEscape("?1006h")
+	cell := term.guessCellSize()
+
+	term.Scrolled(&fyne.ScrollEvent{Scrolled: fyne.Delta{DY: cell.Height * 2}})
+	assert.Equal(t, "\x1b[<64;1;1M\x1b[<64;1;1M", inBuffer.String())
+	inBuffer.Reset()
+	term.Scrolled(&fyne.ScrollEvent{Scrolled: fyne.Delta{DY: -cell.Height}})
+	assert.Equal(t, "\x1b[<65;1;1M", inBuffer.String())
+	assert.Equal(t, 0, term.scrollOffset)
+}
@@ -1,13 +1,19 @@
 package terminal
 
 import (
+	"encoding/hex"
 	"log"
+	"net/url"
 	"os"
-
-	"fyne.io/fyne/v2/storage"
+	"strings"
+	"unicode"
 )
 
-func (t *Terminal) handleOSC(code string) {
+func (s *Screen) handleOSC(code string) {
+	if strings.HasPrefix(code, "1337;") {
+		s.handleITerm2(code[5:])
+		return
+	}
 	if len(code) <= 2 || code[1] != ';' {
 		return
 	}
@@ -15,24 +21,24 @@ func (t *Terminal) handleOSC(code string) {
 	switch code[0] {
 	case '0':
 		// set icon name, if Fyne supports in the future
-		t.setTitle(code[2:])
+		s.setTitle(s.decodeTitle(code[2:]))
 	case '1':
 		// set icon name, if Fyne supports in the future
 	case '2':
-		t.setTitle(code[2:])
+		s.setTitle(s.decodeTitle(code[2:]))
 	case '7':
-		t.setDirectory(code[2:])
+		s.setDirectory(code[2:])
 	default:
-		if t.debug {
+		if s.debug {
 			log.Println("Unrecognised OSC:", code)
 		}
 	}
 }
 
-func (t *Terminal) setDirectory(uri string) {
-	u, err := storage.ParseURI(uri)
+func (s *Screen) setDirectory(uri string) {
+	u, err := url.Parse(uri)
 	if err != nil {
-		// working around a Fyne bug where file URI does not parse host
+		// not a valid URI, so skip past the scheme and host to the path
 		off := 4
 		count := 0
 		for count < 3 && off < len(uri) {
@@ -42,15 +48,56 @@ func (t *Terminal) setDirectory(uri string) {
 			}
 
 		}
-		os.Chdir(uri[off:])
+		s.directory = uri[off:]
+		os.Chdir(s.directory)
 		return
 	}
 
-	// fallback to guessing it's a path
-	os.Chdir(u.Path())
+	s.directory = u.Path
+	os.Chdir(s.directory)
+}
+
+// decodeTitle returns the title sent by the program, which is hex encoded if it has asked for that title mode.
+func (s *Screen) decodeTitle(title string) string {
+	if !s.titleSetHex {
+		return title
+	}
+	if b, err := hex.DecodeString(title); err == nil {
+		return string(b)
+	}
+	return title
 }
 
-func (t *Terminal) setTitle(title string) {
-	t.config.Title = title
-	t.onConfigure()
+// reportTitle sends the current title to the program, hex encoded if it has asked for that title mode.
+func (s *Screen) reportTitle() {
+	title := s.config.Title
+	if s.titleQueryHex {
+		title = hex.EncodeToString([]byte(title))
+	}
+	s.reply([]byte("\x1b]l" + title + "\x1b\\"))
+}
+
+// setTitleModes turns on or off the title modes (XTSMTITLE and XTRMTITLE) in the list of parameters.
+// Titles are always UTF-8, so only the hexadecimal modes are supported.
+func (s *Screen) setTitleModes(modes string, on bool) {
+	for _, mode := range strings.Split(modes, ";") {
+		switch mode {
+		case "0":
+			s.titleSetHex = on
+		case "1":
+			s.titleQueryHex = on
+		}
+	}
+}
+
+func (s *Screen) setTitle(title string) {
+	title = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1 // control characters could confuse the window manager
+		}
+		return r
+	}, title)
+	s.config.Title = title
+	s.onConfigure()
+	s.sendEvent(TerminalEvent{Type: EventTitle, Title: title})
 }
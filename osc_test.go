@@ -1,6 +1,8 @@
 package terminal
 
 import (
+	"bytes"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,11 +10,86 @@ import (
 
 func TestOSC_Title(t *testing.T) {
 	term := New()
-	assert.Equal(t, "", term.config.Title)
+	assert.Equal(t, "", term.screen.config.Title)
 
-	term.handleOSC("0;Test")
-	assert.Equal(t, "Test", term.config.Title)
+	term.screen.handleOSC("0;Test")
+	assert.Equal(t, "Test", term.screen.config.Title)
 
-	term.handleOSC("0;Testing;123")
-	assert.Equal(t, "Testing;123", term.config.Title)
+	term.screen.handleOSC("0;Testing;123")
+	assert.Equal(t, "Testing;123", term.screen.config.Title)
+}
+
+func TestOSC_TitleModes(t *testing.T) {
+	term := New()
+	buf := &bytes.Buffer{}
+	term.in = NopCloser(buf)
+
+	term.handleOutput([]byte("\x1b]2;a\tb\u0085c\x07"))
+	assert.Equal(t, "abc", term.screen.config.Title)
+
+	term.handleOutput([]byte(esc("[>0t") + "\x1b]2;6869\x07" + esc("[21t")))
+	assert.Equal(t, "hi", term.screen.config.Title)
+	assert.Equal(t, "\x1b]lhi\x1b\\", buf.String())
+
+	buf.Reset()
+	term.handleOutput([]byte(esc("[>0T") + esc("[>1t") + "\x1b]2;6869\x07" + esc("[21t")))
+	assert.Equal(t, "6869", term.screen.config.Title)
+	assert.Equal(t, "\x1b]l36383639\x1b\\", buf.String())
+}
+
+func TestOSC_StringTerminator(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 1
+	term.handleOutput([]byte("\x1b]0;X\x9cab"))
+	assert.Equal(t, "X", term.screen.config.Title)
+	assert.Equal(t, "ab", term.screen.content.Text())
+
+	term.handleOutput([]byte("\x1b]0;Y\u009c"))
+	assert.Equal(t, "Y", term.screen.config.Title)
+}
+
+func TestOSC_MaxStringLength(t *testing.T) {
+	term := New()
+	term.handleOutput(append([]byte("\x1b]2;"), bytes.Repeat([]byte{'x'}, 2<<20)...))
+	assert.True(t, term.screen.state.osc)
+	assert.Nil(t, term.screen.state.str)
+
+	term.handleOutput([]byte("\x07\x1b]2;ok\x07"))
+	assert.Equal(t, "ok", term.screen.config.Title)
+
+	term = New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 1
+	term.SetMaxStringLength(4)
+	term.handleOutput([]byte("\x1b]2;abcdef\x07"))
+	assert.Equal(t, "", term.screen.config.Title)
+	assert.Equal(t, "", term.screen.content.Text())
+
+	term.handleOutput([]byte("\x1bP" + "abcdef\x1b\\ok"))
+	assert.False(t, term.screen.state.dcs)
+	assert.Equal(t, "ok", term.screen.content.Text())
+}
+
+func TestOSC_WorkingDirectory(t *testing.T) {
+	term := New()
+	assert.Equal(t, -1, term.ProcessPID())
+	assert.Equal(t, "", term.WorkingDirectory())
+
+	dir, _ := os.Getwd()
+	defer os.Chdir(dir)
+	tmp := os.TempDir()
+	term.screen.handleOSC("7;file://" + tmp)
+	assert.Equal(t, tmp, term.WorkingDirectory())
+}
+
+func TestTerminal_SetTitle(t *testing.T) {
+	term := New()
+	listen := make(chan Config, 1)
+	term.AddListener(listen)
+
+	term.SetTitle("Saved")
+	assert.Equal(t, "Saved", term.Title())
+	assert.Equal(t, "Saved", (<-listen).Title)
+
+	term.screen.handleOSC("2;Remote")
+	assert.Equal(t, "Remote", term.Title())
 }
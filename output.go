@@ -2,20 +2,28 @@ package terminal
 
 import (
 	"bytes"
-	"time"
+	"log"
+	"unicode"
 	"unicode/utf8"
-
-	"fyne.io/fyne/v2/widget"
-	widget2 "github.com/fyne-io/terminal/internal/widget"
 )
 
 const (
-	asciiBell      = 7
-	asciiBackspace = 8
-	asciiEscape    = 27
-
-	noEscape = 5000
-	tabWidth = 8
+	asciiNull       = 0
+	asciiBell       = 7
+	asciiBackspace  = 8
+	asciiCancel     = 0x18
+	asciiSubstitute = 0x1a
+	asciiEscape     = 27
+	asciiDelete     = 0x7f
+	c1StringEnd     = 0x9c // the 8-bit String Terminator (ST)
+
+	noEscape        = 5000
+	maxEscapeLength = 64      // default longest control sequence parameter string before we give up on it
+	maxStringLength = 1 << 20 // default longest OSC, APC or DCS string before we give up on it
+	defaultTabWidth = 8
+	maxTabWidth     = 32
+
+	substituteChar = '␦' // shown when SUB cancels a sequence
 )
 
 var charSetMap = map[charSet]func(rune) rune{
@@ -29,12 +37,43 @@ var charSetMap = map[charSet]func(rune) rune{
 		}
 		return r
 	},
-	charSetAlternate: func(r rune) rune {
-		return r
-	},
+	charSetAlternate:       nrcsMapping(nrcsUK),
+	charSetDutch:           nrcsMapping(nrcsDutch),
+	charSetFinnish:         nrcsMapping(nrcsFinnish),
+	charSetFrench:          nrcsMapping(nrcsFrench),
+	charSetFrenchCanadian:  nrcsMapping(nrcsFrenchCanadian),
+	charSetGerman:          nrcsMapping(nrcsGerman),
+	charSetItalian:         nrcsMapping(nrcsItalian),
+	charSetNorwegianDanish: nrcsMapping(nrcsNorwegianDanish),
+	charSetSpanish:         nrcsMapping(nrcsSpanish),
+	charSetSwedish:         nrcsMapping(nrcsSwedish),
+	charSetSwiss:           nrcsMapping(nrcsSwiss),
+}
+
+// charSetDesignators are the final characters of ESC ( and ESC ) that select each character set.
+var charSetDesignators = map[rune]charSet{
+	'B': charSetANSII,
+	'0': charSetDECSpecialGraphics,
+	'A': charSetAlternate,
+	'4': charSetDutch,
+	'C': charSetFinnish,
+	'5': charSetFinnish,
+	'R': charSetFrench,
+	'f': charSetFrench,
+	'Q': charSetFrenchCanadian,
+	'9': charSetFrenchCanadian,
+	'K': charSetGerman,
+	'Y': charSetItalian,
+	'E': charSetNorwegianDanish,
+	'6': charSetNorwegianDanish,
+	'`': charSetNorwegianDanish,
+	'Z': charSetSpanish,
+	'H': charSetSwedish,
+	'7': charSetSwedish,
+	'=': charSetSwiss,
 }
 
-var specialChars = map[rune]func(t *Terminal){
+var specialChars = map[rune]func(s *Screen){
 	asciiBell:      handleOutputBell,
 	asciiBackspace: handleOutputBackspace,
 	'\n':           handleOutputLineFeed,
@@ -44,6 +83,12 @@ var specialChars = map[rune]func(t *Terminal){
 	'\t':           handleOutputTab,
 	0x0e:           handleShiftOut, // handle switch to G1 character set
 	0x0f:           handleShiftIn,  // handle switch to G0 character set
+	asciiNull:      nil,
+	0x1c:           nil, // file separator
+	0x1d:           nil, // group separator
+	0x1e:           nil, // record separator
+	0x1f:           nil, // unit separator
+	asciiDelete:    nil,
 }
 
 // decSpecialGraphics is for ESC(0 graphics mode
@@ -82,24 +127,91 @@ var decSpecialGraphics = map[rune]rune{
 	'~': '·', // centered dot
 }
 
-type parseState struct {
-	code     string
-	esc      int
-	osc      bool
-	vt100    rune
-	apc      bool
-	printing bool
+// asciiSpecialGraphics approximates the line drawing and symbols of the DEC special graphics using ASCII,
+// as ncurses does, for fonts that do not draw them well.
+var asciiSpecialGraphics = map[rune]rune{
+	'`': '+', 'a': ':', 'f': '\'', 'g': '#', 'j': '+', 'k': '+', 'l': '+', 'm': '+', 'n': '+',
+	'o': '~', 'p': '-', 'q': '-', 'r': '-', 's': '_', 't': '+', 'u': '+', 'v': '+', 'w': '+', 'x': '|',
+	'y': '<', 'z': '>', '{': '*', '|': '!', '}': 'f', '~': 'o',
+}
+
+// mapCharSet returns the character to show for r in the given character set, using any replacement
+// special graphics that have been set for this terminal.
+func (s *Screen) mapCharSet(set charSet, r rune) rune {
+	if set == charSetDECSpecialGraphics {
+		if m, ok := s.specialGraphics[r]; ok {
+			return m
+		}
+	}
+	return charSetMap[set](r)
 }
 
-func (t *Terminal) handleOutput(buf []byte) []byte {
-	if t.hasSelectedText() {
-		t.clearSelectedText()
+// National replacement character sets (NRCS) replace some ASCII punctuation with national characters.
+// https://vt100.net/docs/vt220-rm/chapter2.html
+var (
+	nrcsUK    = map[rune]rune{'#': '£'}
+	nrcsDutch = map[rune]rune{'#': '£', '@': '¾', '[': 'ĳ', '\\': '½', ']': '|',
+		'{': '¨', '|': 'ƒ', '}': '¼', '~': '´'}
+	nrcsFinnish = map[rune]rune{'[': 'Ä', '\\': 'Ö', ']': 'Å', '^': 'Ü', '`': 'é',
+		'{': 'ä', '|': 'ö', '}': 'å', '~': 'ü'}
+	nrcsFrench = map[rune]rune{'#': '£', '@': 'à', '[': '°', '\\': 'ç', ']': '§',
+		'{': 'é', '|': 'ù', '}': 'è', '~': '¨'}
+	nrcsFrenchCanadian = map[rune]rune{'@': 'à', '[': 'â', '\\': 'ç', ']': 'ê', '^': 'î', '`': 'ô',
+		'{': 'é', '|': 'ù', '}': 'è', '~': 'û'}
+	nrcsGerman = map[rune]rune{'@': '§', '[': 'Ä', '\\': 'Ö', ']': 'Ü',
+		'{': 'ä', '|': 'ö', '}': 'ü', '~': 'ß'}
+	nrcsItalian = map[rune]rune{'#': '£', '@': '§', '[': '°', '\\': 'ç', ']': 'é', '`': 'ù',
+		'{': 'à', '|': 'ò', '}': 'è', '~': 'ì'}
+	nrcsNorwegianDanish = map[rune]rune{'@': 'Ä', '[': 'Æ', '\\': 'Ø', ']': 'Å', '^': 'Ü', '`': 'ä',
+		'{': 'æ', '|': 'ø', '}': 'å', '~': 'ü'}
+	nrcsSpanish = map[rune]rune{'#': '£', '@': '§', '[': '¡', '\\': 'Ñ', ']': '¿',
+		'{': '°', '|': 'ñ', '}': 'ç'}
+	nrcsSwedish = map[rune]rune{'@': 'É', '[': 'Ä', '\\': 'Ö', ']': 'Å', '^': 'Ü', '`': 'é',
+		'{': 'ä', '|': 'ö', '}': 'å', '~': 'ü'}
+	nrcsSwiss = map[rune]rune{'#': 'ù', '@': 'à', '[': 'é', '\\': 'ç', ']': 'ê', '^': 'î', '_': 'è', '`': 'ô',
+		'{': 'ä', '|': 'ö', '}': 'ü', '~': 'û'}
+)
+
+// nrcsMapping returns a function that replaces the characters in the given table.
+func nrcsMapping(table map[rune]rune) func(rune) rune {
+	return func(r rune) rune {
+		if m, ok := table[r]; ok {
+			return m
+		}
+		return r
 	}
-	if t.state == nil {
-		t.state = &parseState{
+}
+
+type parseState struct {
+	code       string
+	introducer []byte // the character after ESC, or the C1 control, that opened the sequence in code
+	str        []byte // the content of an OSC, APC or DCS string
+	strLong    bool   // the string was longer than the maximum, it is discarded up to its terminator
+	esc        int
+	osc        bool
+	vt100      rune
+	apc        bool
+	dcs        bool
+	dcsEsc     bool // an escape was read inside a DCS string, it may be the start of the terminator
+	printing   bool
+
+	passthrough *parseState // the state of output unwrapped from a multiplexer passthrough
+}
+
+// handleOutput parses output from the program, the state lock must be held.
+// It returns the bytes of an incomplete character at the end of buf, to be passed again with the next data.
+func (s *Screen) handleOutput(buf []byte) []byte {
+	if s.state == nil {
+		s.state = &parseState{
 			esc: noEscape,
 		}
 	}
+	return s.parseOutput(buf)
+}
+
+// parseOutput handles the characters and sequences in buf using the current parse state.
+// It returns the bytes of an incomplete character at the end of buf, to be passed again with the next data.
+func (s *Screen) parseOutput(buf []byte) []byte {
 	var (
 		size int
 		r    rune
@@ -112,38 +224,59 @@ func (t *Terminal) handleOutput(buf []byte) []byte {
 		if size == 0 {
 			break
 		}
-		if t.state.printing {
-			t.parsePrinting(buf, size)
+		if s.state.printing {
+			s.parsePrinting(buf, size)
+			continue
+		}
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(buf) {
+			break // the rest of this rune will arrive with the next read
+		}
+
+		if r == asciiCancel || r == asciiSubstitute {
+			if s.abortEscape() && r == asciiSubstitute {
+				s.handleOutputChar(substituteChar)
+			}
 			continue
 		}
-		if r == utf8.RuneError && size == 1 {
-			return buf
+
+		if size == 1 && buf[0] == c1StringEnd && (s.state.osc || s.state.dcs || s.state.apc) {
+			r = c1StringEnd // a raw 8-bit ST rather than an invalid UTF-8 byte
 		}
 
+		if s.state.dcs {
+			s.parseDCS(r)
+			continue
+		}
+
+		if c1 := s.c1Control(buf[:size], r); c1 != 0 {
+			s.state.esc = i - 1 // handled as ESC followed by the 7-bit equivalent
+			r = c1
+		}
 		if r == asciiEscape {
-			t.state.esc = i
+			s.state.esc = i
 			continue
 		}
-		if t.state.esc == i-1 {
-			if cont := t.parseEscState(r); cont {
+		if s.state.esc == i-1 {
+			s.state.introducer = append(s.state.introducer[:0], buf[:size]...)
+			if cont := s.parseEscState(r); cont {
 				continue
 			}
-			t.state.esc = noEscape
+			s.state.esc = noEscape
 			continue
 		}
-		if t.state.apc {
-			t.parseAPC(r)
+		if s.state.apc {
+			s.parseAPC(r)
 			continue
 		}
-		if t.state.osc {
-			t.parseOSC(r)
+		if s.state.osc {
+			s.parseOSC(r)
 			continue
-		} else if t.state.vt100 != 0 {
-			t.handleVT100(string([]rune{t.state.vt100, r}))
-			t.state.vt100 = 0
+		} else if s.state.vt100 != 0 {
+			s.handleVT100(string([]rune{s.state.vt100, r}))
+			s.state.vt100 = 0
 			continue
-		} else if t.state.esc != noEscape {
-			t.parseEscape(r)
+		} else if s.state.esc != noEscape {
+			s.parseEscape(r)
 			continue
 		}
 
@@ -151,199 +284,538 @@ func (t *Terminal) handleOutput(buf []byte) []byte {
 			if out == nil {
 				continue
 			}
-			out(t)
+			out(s)
 		} else {
 			// check to see which charset to use
-			if t.useG1CharSet {
-				t.handleOutputChar(charSetMap[t.g1Charset](r))
+			if s.useG1CharSet {
+				s.handleOutputChar(s.mapCharSet(s.g1Charset, r))
 
 			} else {
-				t.handleOutputChar(charSetMap[t.g0Charset](r))
+				s.handleOutputChar(s.mapCharSet(s.g0Charset, r))
 			}
 		}
 	}
 
 	// record progress for next chunk of buffer
-	if t.state.esc != noEscape {
-		t.state.esc = t.state.esc - i
+	if s.state.esc != noEscape {
+		s.state.esc = s.state.esc - i
 	}
 	return buf
 }
 
-func (t *Terminal) parseEscState(r rune) (shouldContinue bool) {
+// c1Control returns the 7-bit equivalent of an 8-bit C1 control character, if they are enabled,
+// or 0 if the rune is not one. Either a raw byte or its UTF-8 encoding is accepted.
+func (s *Screen) c1Control(b []byte, r rune) rune {
+	if !s.c1Controls || s.state.osc || s.state.apc || s.state.esc != noEscape {
+		return 0
+	}
+	if len(b) == 1 && b[0] >= 0x80 && b[0] < 0xa0 {
+		r = rune(b[0])
+	}
+	if r < 0x80 || r >= 0xa0 {
+		return 0
+	}
+	return r - 0x40
+}
+
+// abortEscape discards any partially received sequence and returns the parser to its ground state.
+// It returns true if there was a sequence in progress.
+func (s *Screen) abortEscape() bool {
+	active := s.state.esc != noEscape || s.state.osc || s.state.apc || s.state.dcs || s.state.vt100 != 0
+	s.state.code = ""
+	s.state.str = nil
+	s.state.strLong = false
+	s.state.esc = noEscape
+	s.state.osc = false
+	s.state.apc = false
+	s.state.dcs = false
+	s.state.dcsEsc = false
+	s.state.vt100 = 0
+	return active
+}
+
+func (s *Screen) parseEscState(r rune) (shouldContinue bool) {
 	switch r {
 	case '[':
 		return true
 	case '\\':
-		if t.state.osc {
-			t.handleOSC(t.state.code)
+		code, ok := s.endString()
+		s.state.code = ""
+		if s.state.osc {
+			s.state.osc = false
+			if ok {
+				s.handleOSC(code)
+			}
+		}
+		if s.state.apc {
+			s.state.apc = false
+			if ok {
+				s.handleAPC(code)
+			}
 		}
-		t.state.code = ""
-		t.state.osc = false
 	case ']':
-		t.state.osc = true
+		s.state.osc = true
 	case '(', ')':
-		t.state.vt100 = r
+		s.state.vt100 = r
 	case '7':
-		t.savedRow = t.cursorRow
-		t.savedCol = t.cursorCol
+		s.saveCursor()
 	case '8':
-		t.cursorRow = t.savedRow
-		t.cursorCol = t.savedCol
+		s.restoreCursor()
 	case 'D':
-		t.scrollDown()
+		s.index()
 	case 'M':
-		t.scrollUp()
+		s.reverseIndex()
 	case '_':
-		t.state.apc = true
+		s.state.apc = true
+	case 'P':
+		s.state.dcs = true
 	case '=', '>':
 	}
 	return false
 }
 
-func (t *Terminal) parseEscape(r rune) {
-	t.state.code += string(r)
-	if (r < '0' || r > '9') && r != ';' && r != '=' && r != '?' && r != '>' {
-		t.handleEscape(t.state.code)
-		t.state.code = ""
-		t.state.esc = noEscape
+// saveCursor keeps the cursor position, text attributes, character sets and origin mode (DECSC).
+func (s *Screen) saveCursor() {
+	s.savedCursor = savedCursor{
+		row: s.cursorRow, col: s.cursorCol,
+		fg: s.currentFG, bg: s.currentBG,
+		bold: s.bold, blinking: s.blinking, protected: s.protected, overline: s.overline,
+		concealed: s.concealed,
+		g0Charset: s.g0Charset, g1Charset: s.g1Charset,
+		useG1CharSet: s.useG1CharSet, originMode: s.originMode,
 	}
 }
 
-func (t *Terminal) parsePrinting(buf []byte, size int) {
-	t.printData = append(t.printData, buf[:size]...)
-	if bytes.HasSuffix(t.printData, []byte{asciiEscape, '[', '4', 'i'}) {
+// restoreCursor returns to the state kept by saveCursor (DECRC).
+func (s *Screen) restoreCursor() {
+	c := s.savedCursor
+	s.cursorRow, s.cursorCol = c.row, c.col
+	s.currentFG, s.currentBG = c.fg, c.bg
+	s.bold, s.blinking, s.protected, s.overline = c.bold, c.blinking, c.protected, c.overline
+	s.concealed = c.concealed
+	s.g0Charset, s.g1Charset = c.g0Charset, c.g1Charset
+	s.useG1CharSet, s.originMode = c.useG1CharSet, c.originMode
+	if s.cursorMoved != nil {
+		s.cursorMoved()
+	}
+}
+
+func (s *Screen) parseEscape(r rune) {
+	s.state.code += string(r)
+	if (r < '0' || r > '9') && r != ';' && r != '=' && r != '?' && r != '>' && !isIntermediate(r) {
+		s.handleEscape(s.state.code)
+		s.state.code = ""
+		s.state.esc = noEscape
+		return
+	}
+
+	if s.maxEscapeLength > 0 && len(s.state.code) > s.maxEscapeLength {
+		seq := string(s.state.introducer) + s.state.code
+		if s.debug {
+			log.Println("Escape sequence too long, printing it instead:", seq)
+		}
+		s.abortEscape()
+		for _, c := range seq {
+			if c != utf8.RuneError && unicode.IsPrint(c) { // an 8-bit introducer cannot be shown
+				s.handleOutputChar(c)
+			}
+		}
+	}
+}
+
+func (s *Screen) parsePrinting(buf []byte, size int) {
+	s.printData = append(s.printData, buf[:size]...)
+	if bytes.HasSuffix(s.printData, []byte{asciiEscape, '[', '4', 'i'}) {
 		// Handle the end of printing
-		t.printData = t.printData[:len(t.printData)-4]
-		escapePrinterMode(t, "4")
-		t.state.esc = noEscape
+		s.printData = s.printData[:len(s.printData)-4]
+		escapePrinterMode(s, "4")
+		s.state.esc = noEscape
 	}
 }
 
-func (t *Terminal) parseAPC(r rune) {
-	if r == 0 {
-		t.handleAPC(t.state.code)
-		t.state.code = ""
-		t.state.apc = false
+func (s *Screen) parseAPC(r rune) {
+	if r == 0 || r == c1StringEnd {
+		s.state.apc = false
+		if code, ok := s.endString(); ok {
+			s.handleAPC(code)
+		}
 	} else {
-		t.state.code += string(r)
+		s.appendString(string(r))
+	}
+}
+
+func (s *Screen) parseDCS(r rune) {
+	if s.state.dcsEsc {
+		s.state.dcsEsc = false
+		switch r {
+		case '\\':
+			s.state.dcs = false
+			if code, ok := s.endString(); ok {
+				s.handleDCS(code)
+			}
+		case asciiEscape:
+			s.appendString(string([]rune{asciiEscape, asciiEscape})) // an escaped ESC, as used by tmux
+		default:
+			s.appendString(string([]rune{asciiEscape, r}))
+		}
+		return
 	}
+
+	if r == asciiEscape {
+		s.state.dcsEsc = true
+		return
+	}
+	if r == c1StringEnd {
+		s.state.dcs = false
+		if code, ok := s.endString(); ok {
+			s.handleDCS(code)
+		}
+		return
+	}
+	s.appendString(string(r))
 }
 
-func (t *Terminal) parseOSC(r rune) {
-	if r == asciiBell || r == 0 {
-		t.handleOSC(t.state.code)
-		t.state.code = ""
-		t.state.osc = false
+func (s *Screen) parseOSC(r rune) {
+	if r == asciiBell || r == 0 || r == c1StringEnd {
+		s.state.osc = false
+		if code, ok := s.endString(); ok {
+			s.handleOSC(code)
+		}
 	} else {
-		t.state.code += string(r)
+		s.appendString(string(r))
 	}
 }
 
-func (t *Terminal) handleOutputChar(r rune) {
-	if t.cursorCol >= int(t.config.Columns) || t.cursorRow >= int(t.config.Rows) {
-		return // TODO handle wrap?
+// appendString adds to the content of the OSC, APC or DCS string being received.
+// If the string grows beyond the maximum length it is discarded, along with the rest of it up to the terminator.
+func (s *Screen) appendString(str string) {
+	if s.state.strLong {
+		return
 	}
-	for len(t.content.Rows)-1 < t.cursorRow {
-		t.content.Rows = append(t.content.Rows, widget.TextGridRow{})
+	if s.maxStringLength > 0 && len(s.state.str)+len(str) > s.maxStringLength {
+		if s.debug {
+			log.Println("String sequence longer than", s.maxStringLength, "bytes, discarding it")
+		}
+		s.state.str = nil
+		s.state.strLong = true
+		return
 	}
+	s.state.str = append(s.state.str, str...)
+}
 
-	var cellStyle widget.TextGridStyle
-	cellStyle = &widget.CustomTextGridStyle{FGColor: t.currentFG, BGColor: t.currentBG}
-	for len(t.content.Rows[t.cursorRow].Cells)-1 < t.cursorCol {
-		newCell := widget.TextGridCell{
-			Rune:  ' ',
-			Style: cellStyle,
-		}
-		t.content.Rows[t.cursorRow].Cells = append(t.content.Rows[t.cursorRow].Cells, newCell)
+// endString returns the content of the OSC, APC or DCS string that has just been terminated,
+// and false if it was discarded for being too long.
+func (s *Screen) endString() (string, bool) {
+	code, ok := string(s.state.str), !s.state.strLong
+	s.state.str = nil
+	s.state.strLong = false
+	return code, ok
+}
+
+func (s *Screen) handleOutputChar(r rune) {
+	width := runeWidth(r)
+	if s.autoWrap && s.config.Columns > 0 && s.cursorCol+width > int(s.config.Columns) {
+		s.cursorCol = 0
+		handleOutputLineFeed(s)
 	}
-	if t.blinking {
-		cellStyle = widget2.NewTermTextGridStyle(t.currentFG, t.currentBG, t.highlightBitMask, t.blinking)
+	if s.cursorCol >= int(s.config.Columns) {
+		s.cursorCol = int(s.config.Columns) - 1 // without auto-wrap the last column is overwritten
 	}
-	t.content.SetCell(t.cursorRow, t.cursorCol, widget.TextGridCell{Rune: r, Style: cellStyle})
-	t.cursorCol++
+	if s.cursorCol < 0 || s.cursorRow >= int(s.config.Rows) {
+		return
+	}
+	if s.cursorCol+width > int(s.config.Columns) {
+		width = 1 // no room for the padding cell, so it will be cropped
+	}
+	s.ensureRow(s.cursorRow)
+
+	fg := s.currentFG
+	if s.bold && s.boldIsBright {
+		fg = getBrightColor(fg)
+	}
+	cellStyle := s.currentStyle()
+	cellStyle.Foreground = fg
+	if s.insertMode {
+		s.insertCells(width)
+	}
+	s.breakWideChars(s.cursorRow, s.cursorCol, width)
+	s.content.SetCell(s.cursorRow, s.cursorCol, Cell{Rune: r, Style: cellStyle})
+	if width == 2 {
+		s.content.SetCell(s.cursorRow, s.cursorCol+1, Cell{Rune: wideCharPadding, Style: cellStyle})
+	}
+	s.cursorCol += width
 }
 
-func (t *Terminal) ringBell() {
-	t.bell = true
-	t.Refresh()
+// breakWideChars blanks the remaining half of any double width character that is about
+// to be partially overwritten by writing width cells at the given position.
+func (s *Screen) breakWideChars(row, col, width int) {
+	cells := s.content.Rows[row].Cells
+	if col > 0 && col < len(cells) && cells[col].Rune == wideCharPadding {
+		cells[col-1].Rune = ' '
+	}
+	if end := col + width; end < len(cells) && cells[end].Rune == wideCharPadding {
+		cells[end].Rune = ' '
+	}
+}
 
-	time.Sleep(time.Millisecond * 300)
-	t.bell = false
-	t.Refresh()
+func (s *Screen) ringBell() {
+	s.sendEvent(TerminalEvent{Type: EventBell})
+	if s.bellRung != nil {
+		s.bellRung()
+	}
 }
 
-func (t *Terminal) scrollUp() {
-	for i := t.scrollBottom; i > t.scrollTop; i-- {
-		t.content.Rows[i] = t.content.Row(i - 1)
+func (s *Screen) scrollUp() {
+	for i := s.scrollBottom; i > s.scrollTop; i-- {
+		s.content.Rows[i] = s.content.Row(i - 1)
 	}
-	t.content.Rows[t.scrollTop] = widget.TextGridRow{}
-	t.content.Refresh()
+	s.shiftImages(1)
+	s.content.Rows[s.scrollTop] = s.blankRow()
+	s.content.MarkRowsDirty(s.scrollTop, s.scrollBottom)
 }
 
-func (t *Terminal) scrollDown() {
-	i := t.scrollTop
-	for ; i < t.scrollBottom && i < len(t.content.Rows)-1; i++ {
-		t.content.Rows[i] = t.content.Row(i + 1)
+func (s *Screen) scrollDown() {
+	if !s.altScreen && s.scrollTop == 0 && len(s.content.Rows) > 0 {
+		s.pushScrollback(s.content.Rows[0])
+	}
+	s.shiftImages(-1)
+
+	i := s.scrollTop
+	for ; i < s.scrollBottom && i < len(s.content.Rows)-1; i++ {
+		s.content.Rows[i] = s.content.Row(i + 1)
 	}
-	for ; i < len(t.content.Rows); i++ {
-		if len(t.content.Rows) > t.scrollBottom {
-			t.content.Rows[t.scrollBottom] = widget.TextGridRow{}
+	for ; i < len(s.content.Rows); i++ {
+		if len(s.content.Rows) > s.scrollBottom {
+			s.content.Rows[s.scrollBottom] = s.blankRow()
 		} else {
-			t.content.Rows = append(t.content.Rows, widget.TextGridRow{})
+			s.content.Rows = append(s.content.Rows, s.blankRow())
 		}
 	}
-	t.content.Refresh()
+	s.content.MarkRowsDirty(s.scrollTop, s.scrollBottom)
 }
 
-func handleOutputBackspace(t *Terminal) {
-	row := t.content.Row(t.cursorRow)
-	if len(row.Cells) == 0 {
+// pushScrollback keeps a line that has scrolled off the top of the main screen.
+func (s *Screen) pushScrollback(row gridRow) {
+	s.scrollback = append(s.scrollback, row)
+	if s.scrolledOff != nil {
+		s.scrolledOff()
+	}
+	if len(s.scrollback) > scrollbackLines {
+		s.scrollback = s.scrollback[len(s.scrollback)-scrollbackLines:]
+	}
+}
+
+// setAltScreen switches between the main screen and the alternate screen used by full screen applications.
+// The content of each screen is kept unchanged while the other is shown.
+func (s *Screen) setAltScreen(alt bool) {
+	if alt == s.altScreen {
 		return
 	}
-	t.moveCursor(t.cursorRow, t.cursorCol-1)
+
+	s.altScreen = alt
+	if alt {
+		s.mainRows = s.content.Rows
+		s.content.Rows = s.altRows
+		s.altRows = nil
+		s.mainImages, s.images = s.images, nil
+	} else {
+		s.altRows = s.content.Rows
+		s.content.Rows = s.mainRows
+		s.mainRows = nil
+		s.images, s.mainImages = s.mainImages, nil
+	}
+	s.content.MarkRowsDirty(0, int(s.config.Rows)-1)
+}
+
+// clearAltScreen erases the alternate screen, which must be the one being shown.
+func (s *Screen) clearAltScreen() {
+	s.content.Rows = nil
+	s.images = nil
+	s.content.MarkRowsDirty(0, int(s.config.Rows)-1)
+}
+
+// blankCell returns the cell used for erased positions.
+// If a background colour is set the cell is filled with that colour (background colour erase).
+func (s *Screen) blankCell() Cell {
+	if s.currentBG == nil {
+		return Cell{Rune: ' '}
+	}
+	return Cell{Rune: ' ', Style: CellStyle{Background: s.currentBG}}
 }
 
-func handleOutputBell(t *Terminal) {
-	go t.ringBell()
+// blankRow returns a row of blank cells, as wide as the terminal, for newly exposed lines.
+func (s *Screen) blankRow() gridRow {
+	if s.config.Columns == 0 {
+		return gridRow{}
+	}
+
+	cells := make([]Cell, s.config.Columns)
+	cell := s.blankCell()
+	for i := range cells {
+		cells[i] = cell
+	}
+	return gridRow{Cells: cells}
 }
 
-func handleOutputCarriageReturn(t *Terminal) {
-	t.moveCursor(t.cursorRow, 0)
+// ensureRow makes sure that the given row, and all rows above it, exist and are as wide as the terminal.
+func (s *Screen) ensureRow(row int) {
+	for len(s.content.Rows) <= row {
+		s.content.Rows = append(s.content.Rows, gridRow{})
+		s.padRow(len(s.content.Rows) - 1)
+	}
+	s.padRow(row)
+}
+
+// padRow fills or crops the cells of an existing row so that it is exactly as wide as the terminal.
+// Cells added to the row are blank, without any background colour.
+func (s *Screen) padRow(row int) {
+	cols := int(s.config.Columns)
+	if cols == 0 || row < 0 || row >= len(s.content.Rows) {
+		return
+	}
+
+	cells := s.content.Rows[row].Cells
+	if len(cells) == cols {
+		return
+	}
+	if len(cells) > cols {
+		cells = cells[:cols]
+	}
+	for len(cells) < cols {
+		cells = append(cells, Cell{Rune: ' '})
+	}
+	s.content.Rows[row].Cells = cells
+	s.content.MarkRowDirty(row)
 }
 
-func handleOutputLineFeed(t *Terminal) {
-	if t.cursorRow == t.scrollBottom {
-		t.scrollDown()
-		if t.newLineMode {
-			t.moveCursor(t.cursorRow, 0)
+// eraseCells replaces the cells of a row from column from up to, but not including, column to with blank cells.
+func (s *Screen) eraseCells(row, from, to int) {
+	if row < 0 || row >= len(s.content.Rows) {
+		return
+	}
+	s.padRow(row)
+	s.splitWideChar(row, from)
+	s.splitWideChar(row, to)
+
+	cells := s.content.Rows[row].Cells
+	if to > len(cells) {
+		to = len(cells)
+	}
+	blank := s.blankCell()
+	for i := from; i < to; i++ {
+		cells[i] = blank
+	}
+	s.content.MarkRowDirty(row)
+}
+
+// eraseUnprotectedCells blanks the cells in a row from column from up to, but not including, to
+// unless they were protected using DECSCA.
+func (s *Screen) eraseUnprotectedCells(row, from, to int) {
+	if row < 0 || row >= len(s.content.Rows) {
+		return
+	}
+	s.padRow(row)
+	s.splitWideChar(row, from)
+	s.splitWideChar(row, to)
+
+	cells := s.content.Rows[row].Cells
+	if to > len(cells) {
+		to = len(cells)
+	}
+	blank := s.blankCell()
+	for i := from; i < to; i++ {
+		if cells[i].Style.Protected {
+			continue
 		}
+		cells[i] = blank
+	}
+	s.content.MarkRowDirty(row)
+}
+
+func handleOutputBackspace(s *Screen) {
+	if s.cursorCol == 0 && s.autoWrap && s.reverseWrap && s.cursorRow > 0 {
+		s.moveCursor(s.cursorRow-1, int(s.config.Columns)-1)
 		return
 	}
-	if t.newLineMode {
-		t.moveCursor(t.cursorRow+1, 0)
+	row := s.content.Row(s.cursorRow)
+	if len(row.Cells) == 0 {
+		return
+	}
+	s.clearWrapPending()
+	s.moveCursor(s.cursorRow, s.cursorCol-1)
+}
+
+// clearWrapPending moves the cursor back to the last column if it has passed it, waiting to wrap.
+// Relative movements then start from the column where the cursor is shown.
+func (s *Screen) clearWrapPending() {
+	if cols := int(s.config.Columns); cols > 0 && s.cursorCol >= cols {
+		s.cursorCol = cols - 1
+	}
+}
+
+func handleOutputBell(s *Screen) {
+	go s.ringBell()
+}
+
+func handleOutputCarriageReturn(s *Screen) {
+	s.moveCursor(s.cursorRow, 0)
+}
+
+func handleOutputLineFeed(s *Screen) {
+	s.index()
+	if s.newLineMode {
+		s.moveCursor(s.cursorRow, 0)
+	}
+}
+
+// index moves the cursor down a line (IND), scrolling the scroll region if it is on the bottom margin.
+// Below the scroll region the cursor stops at the last row, and nothing scrolls.
+func (s *Screen) index() {
+	if s.cursorRow == s.scrollBottom {
+		s.scrollDown()
 		return
 	}
-	t.moveCursor(t.cursorRow+1, t.cursorCol)
+	s.moveCursor(s.cursorRow+1, s.cursorCol)
+}
 
+// reverseIndex moves the cursor up a line (RI), scrolling the scroll region if it is on the top margin.
+// Above the scroll region the cursor stops at the first row, and nothing scrolls.
+func (s *Screen) reverseIndex() {
+	if s.cursorRow == s.scrollTop {
+		s.scrollUp()
+		return
+	}
+	s.moveCursor(s.cursorRow-1, s.cursorCol)
 }
 
-func handleOutputTab(t *Terminal) {
-	end := t.cursorCol - t.cursorCol%tabWidth + tabWidth
-	for t.cursorCol < end {
-		t.handleOutputChar(' ')
+func handleOutputTab(s *Screen) {
+	width := s.tabWidth
+	if width <= 0 {
+		width = defaultTabWidth
+	}
+	end := s.cursorCol - s.cursorCol%width + width
+	if end >= int(s.config.Columns) {
+		end = int(s.config.Columns) - 1
 	}
+	for s.cursorCol < end {
+		s.handleOutputChar(' ')
+	}
+}
+
+func handleShiftOut(s *Screen) {
+	s.useG1CharSet = true
 }
 
-func handleShiftOut(t *Terminal) {
-	t.useG1CharSet = true
+func handleShiftIn(s *Screen) {
+	s.useG1CharSet = false
 }
 
-func handleShiftIn(t *Terminal) {
-	t.useG1CharSet = false
+// SetPrinterFunc sets the printer function which is executed when printing.
+func (s *Screen) SetPrinterFunc(printerFunc PrinterFunc) {
+	s.printer = printerFunc
 }
 
 // SetPrinterFunc sets the printer function which is executed when printing.
 func (t *Terminal) SetPrinterFunc(printerFunc PrinterFunc) {
-	t.printer = printerFunc
+	t.screen.SetPrinterFunc(printerFunc)
 }
@@ -1,7 +1,9 @@
 package terminal
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"fyne.io/fyne/v2"
 
@@ -12,10 +14,304 @@ func TestTerminal_Backspace(t *testing.T) {
 	term := New()
 	term.Resize(fyne.NewSize(50, 50))
 	term.handleOutput([]byte("Hi"))
-	assert.Equal(t, "Hi", term.content.Text())
+	assert.Equal(t, "Hi", term.screen.content.Text())
 
 	term.handleOutput([]byte{asciiBackspace})
 	term.handleOutput([]byte("ello"))
 
-	assert.Equal(t, "Hello", term.content.Text())
+	assert.Equal(t, "Hello", term.screen.content.Text())
+}
+
+func TestTerminal_ReverseWraparound(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("ab\r\ncd\r\b"))
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 0, term.screen.cursorCol)
+
+	term.handleOutput([]byte("\x1b[?45h\b"))
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 4, term.screen.cursorCol)
+
+	term.handleOutput([]byte("X"))
+	assert.Equal(t, "ab  X\ncd", term.screen.content.Text())
+}
+
+func TestTerminal_AutoWrap(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("HelloWorld"))
+	assert.Equal(t, "Hello\nWorld", term.screen.content.Text())
+
+	term = New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte("\x1b[?7lHelloWorld"))
+	assert.Equal(t, "Helld", term.screen.content.Text())
+}
+
+func TestTerminal_AutoWrapOff_OverwritesLastColumn(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte("\x1b[?7labcdefg"))
+	assert.Equal(t, "abcdg", term.screen.content.Text())
+	assert.Equal(t, 0, term.screen.cursorRow)
+}
+
+func TestTerminal_WrapPending(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("Hello\rJ"))
+	assert.Equal(t, "Jello", term.screen.content.Text())
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 1, term.screen.cursorCol)
+
+	term.handleOutput([]byte("\rHello\bp"))
+	assert.Equal(t, "Helpo", term.screen.content.Text())
+	assert.Equal(t, 4, term.screen.cursorCol)
+
+	term.handleOutput([]byte("\rHello" + esc("[2D") + "x"))
+	assert.Equal(t, "Hexlo", term.screen.content.Text())
+}
+
+func TestTerminal_ScrollBackgroundColorErase(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 3
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("ab\r\ncd" + esc("[41m") + "\n"))
+	assert.Equal(t, "cd\n   ", term.screen.content.Text())
+
+	row := term.screen.content.Row(1)
+	assert.Equal(t, 3, len(row.Cells))
+	for _, c := range row.Cells {
+		assert.Equal(t, basicColors[1], c.Style.Background)
+	}
+}
+
+func TestTerminal_IgnoredControls(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte("a\x7fb\x00c\x1cd\x1fe"))
+	assert.Equal(t, "abcde", term.screen.content.Text())
+	assert.Equal(t, 5, term.screen.cursorCol)
+}
+
+func TestTerminal_CancelEscape(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte{asciiEscape, '[', '3', asciiCancel, 'A'})
+	assert.Equal(t, "A", term.screen.content.Text())
+	assert.Equal(t, 1, term.screen.cursorCol)
+	assert.Equal(t, "", term.screen.state.code)
+
+	term.handleOutput([]byte{asciiEscape, ']', '0', ';', 'x', asciiSubstitute, 'B'})
+	assert.Equal(t, "A␦B", term.screen.content.Text())
+	assert.False(t, term.screen.state.osc)
+	assert.Equal(t, "", term.screen.config.Title)
+}
+
+func TestTerminal_C1Controls(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte("\u011c\u009b1C"))
+	assert.Equal(t, "\u011c\u009b1C", term.screen.content.Text())
+
+	term = New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 1
+	term.SetC1Controls(true)
+	term.handleOutput([]byte("\u011ca\x9b2Cb\u009bDc"))
+	assert.Equal(t, "\u011ca  c", term.screen.content.Text())
+	term.handleOutput([]byte("\x9d2;T\x9c"))
+	assert.Equal(t, "T", term.screen.config.Title)
+}
+
+func TestTerminal_EscapeTooLong(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 200
+	term.screen.config.Rows = 1
+	digits := strings.Repeat("1", 100)
+	term.handleOutput([]byte(esc("[" + digits + "A")))
+	assert.Equal(t, "["+digits+"A", term.screen.content.Text())
+	assert.Equal(t, noEscape, term.screen.state.esc)
+
+	term = New()
+	term.screen.config.Columns = 200
+	term.screen.config.Rows = 1
+	term.SetC1Controls(true)
+	term.SetMaxControlSequenceLength(4)
+	term.handleOutput([]byte("\x9b12345A"))
+	assert.Equal(t, "12345A", term.screen.content.Text()) // the 8-bit CSI is not shown
+
+	term = New()
+	term.screen.config.Columns = 200
+	term.screen.config.Rows = 1
+	term.SetMaxControlSequenceLength(0)
+	term.handleOutput([]byte(esc("["+digits+"C") + "x"))
+	assert.Equal(t, strings.Repeat(" ", 199)+"x", term.screen.content.Text())
+}
+
+func TestTerminal_SplitRune(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 1
+	data := []byte("a世b")
+
+	leftOver := term.handleOutput(data[:3])
+	assert.Equal(t, data[1:3], leftOver)
+	assert.Equal(t, "a", term.screen.content.Text())
+
+	leftOver = term.handleOutput(append(leftOver, data[3:]...))
+	assert.Equal(t, 0, len(leftOver))
+	assert.Equal(t, "a世b", term.screen.content.Text())
+}
+
+func TestTerminal_WideChars(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 4
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("a世b"))
+	assert.Equal(t, "a世b", term.screen.content.Text())
+	assert.Equal(t, 4, term.screen.cursorCol)
+	assert.Equal(t, 4, len(term.screen.content.Row(0).Cells))
+
+	term.screen.moveCursor(0, 2)
+	term.handleOutput([]byte("x"))
+	assert.Equal(t, "a xb", term.screen.content.Text())
+
+	term.handleOutput([]byte("\r\nabc世"))
+	assert.Equal(t, "abc\n世", term.screen.content.Text())
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 2, term.screen.cursorCol)
+}
+
+func TestTerminal_WideCharsErase(t *testing.T) {
+	for name, tt := range map[string]struct {
+		seq, want string
+	}{
+		"erase line from padding": {seq: "\x1b[2G\x1b[C\x1b[K", want: "a"},
+		"erase line to padding":   {seq: "\x1b[2G\x1b[C\x1b[1K", want: "   b"},
+		"delete at padding":       {seq: "\x1b[2G\x1b[C\x1b[P", want: "a b"},
+		"insert at padding":       {seq: "\x1b[2G\x1b[C\x1b[@", want: "a   b"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			term := New()
+			term.screen.config.Columns = 6
+			term.screen.config.Rows = 1
+			term.handleOutput([]byte("a世b"))
+			term.handleOutput([]byte(tt.seq))
+			assert.Equal(t, tt.want, strings.TrimRight(term.screen.content.Text(), " "))
+		})
+	}
+}
+
+func TestTerminal_WideCharsCursor(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 6
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte("a世b"))
+
+	term.handleOutput([]byte("\x1b[1;3H"))
+	assert.Equal(t, 1, term.screen.cursorCol)
+	term.handleOutput([]byte("x"))
+	assert.Equal(t, "ax b", strings.TrimRight(term.screen.content.Text(), " "))
+}
+
+func TestHandleOutput_Bell(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	rung := make(chan bool, 1)
+	term.SetBellHandler(func() {
+		rung <- true
+	})
+	term.SetVisualBell(true)
+
+	term.handleOutput([]byte{asciiBell})
+	select {
+	case <-rung:
+	case <-time.After(time.Second):
+		t.Fatal("bell handler was not called")
+	}
+	inverted := func() bool {
+		term.screen.stateLock.Lock()
+		defer term.screen.stateLock.Unlock()
+		return term.grid.Inverted
+	}
+	assert.Eventually(t, inverted, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return !inverted() }, time.Second, 10*time.Millisecond)
+}
+
+func TestHandleOutput_RowsAreTerminalWidth(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 6
+	term.screen.config.Rows = 5
+	term.screen.scrollBottom = 4
+	term.handleOutput([]byte("ab\r\n" + esc("[4;3H") + "c世d" + esc("[2@") + esc("[1;2H") + esc("[K") +
+		esc("[3P") + esc("[2;1H") + esc("[L") + esc("[M") + esc("[J") + "xy\n\n\n\nz"))
+
+	assert.Equal(t, 5, len(term.screen.content.Rows))
+	for i, row := range term.screen.content.Rows {
+		assert.Equal(t, 6, len(row.Cells), "row %d", i)
+	}
+}
+
+func TestHandleOutput_TabWidth(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 20
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("a\tb"))
+	assert.Equal(t, 9, term.screen.cursorCol)
+
+	term.SetTabWidth(4)
+	term.handleOutput([]byte("\r\na\tb\tc"))
+	assert.Equal(t, "a       b\na   b   c", term.screen.content.Text())
+
+	term.SetTabWidth(100)
+	assert.Equal(t, maxTabWidth, term.screen.tabWidth)
+}
+
+func TestTerminal_SaveRestoreCursorState(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("ab" + esc("[31m") + "\x1b(0" + "\x1b7" + esc("[0m") + "\x1b(B" + esc("[2;4H") + "x" + "\x1b8q"))
+
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 3, term.screen.cursorCol)
+	assert.Equal(t, "ab─\n   x", term.screen.content.Text())
+	assert.Equal(t, basicColors[1], term.screen.content.Row(0).Cells[2].Style.Foreground)
+
+	term.handleOutput([]byte(esc("[0m") + "\x1b(B\rHello\x1b7\r\x1b8!"))
+	assert.Equal(t, "Hello\n!  x", term.screen.content.Text()) // the pending wrap was restored
+}
+
+func TestTerminal_SpecialGraphics(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte("\x1b(0lqk\x1b(Bq"))
+	assert.Equal(t, "┌─┐q", term.screen.content.Text())
+
+	term.SetASCIIBoxDrawing(true)
+	term.handleOutput([]byte("\r\x1b(0lqkx"))
+	assert.Equal(t, "+-+|", term.screen.content.Text())
+
+	term.SetSpecialGraphicsMap(map[rune]rune{'q': '='})
+	term.handleOutput([]byte("\rlqk"))
+	assert.Equal(t, "┌=┐|", term.screen.content.Text())
 }
@@ -80,15 +80,15 @@ func TestHandleOutput_Printing(t *testing.T) {
 			terminal := New()
 			terminal.Resize(fyne.NewSize(50, 50))
 			var spooledData []byte
-			terminal.printer = PrinterFunc(func(d []byte) {
+			terminal.screen.printer = PrinterFunc(func(d []byte) {
 				spooledData = d
 			})
 			terminal.handleOutput(test.inputSeq)
 
-			assert.Equal(t, test.expectedScreenData, terminal.content.Text())
-			assert.Equal(t, test.expectedPrintingState, terminal.state.printing)
+			assert.Equal(t, test.expectedScreenData, terminal.screen.content.Text())
+			assert.Equal(t, test.expectedPrintingState, terminal.screen.state.printing)
 			assert.Equal(t, test.expectedSpooledData, spooledData)
-			assert.Equal(t, test.expectedPrintData, terminal.printData)
+			assert.Equal(t, test.expectedPrintData, terminal.screen.printData)
 		})
 	}
 }
@@ -99,7 +99,7 @@ var examplePDFData []byte
 func TestHandleOutput_Printing_PDF(t *testing.T) {
 	terminal := New()
 	var spooledData []byte
-	terminal.printer = PrinterFunc(func(d []byte) {
+	terminal.screen.printer = PrinterFunc(func(d []byte) {
 		spooledData = d
 	})
 
@@ -0,0 +1,26 @@
+package terminal
+
+// ProcessPID returns the process ID of the shell running in this terminal.
+// Returns -1 if there is no local process, for example before the shell is started or when using RunWithConnection.
+func (t *Terminal) ProcessPID() int {
+	t.closeLock.Lock()
+	cmd := t.cmd
+	t.closeLock.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return -1
+	}
+	return cmd.Process.Pid
+}
+
+// WorkingDirectory returns the current directory of the program running in the terminal.
+// On Linux this is looked up from the foreground process, otherwise, or if that fails, it is
+// the last directory reported by the shell using OSC 7. An empty string is returned if it is not known.
+func (t *Terminal) WorkingDirectory() string {
+	if pid := t.ProcessPID(); pid != -1 {
+		if dir := processDirectory(pid); dir != "" {
+			return dir
+		}
+	}
+
+	return t.screen.directory
+}
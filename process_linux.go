@@ -0,0 +1,50 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processDirectory returns the directory of the foreground process of the terminal that the given
+// process is attached to, or of the process itself, using the proc filesystem.
+func processDirectory(pid int) string {
+	if fg := foregroundPID(pid); fg > 0 {
+		if dir, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", fg)); err == nil {
+			return dir
+		}
+	}
+	if dir, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid)); err == nil {
+		return dir
+	}
+	return ""
+}
+
+// foregroundPID returns the foreground process group of the terminal that the given process is attached to,
+// or -1 if it is not available.
+func foregroundPID(pid int) int {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return -1
+	}
+	return statTPGID(string(stat))
+}
+
+// statTPGID returns the foreground process group field of the contents of a /proc/<pid>/stat file.
+func statTPGID(stat string) int {
+	// the command name may contain spaces, so skip to the closing bracket before splitting
+	end := strings.LastIndexByte(stat, ')')
+	if end == -1 {
+		return -1
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 6 {
+		return -1
+	}
+	tpgid, err := strconv.Atoi(fields[5]) // state, ppid, pgrp, session, tty_nr then tpgid
+	if err != nil {
+		return -1
+	}
+	return tpgid
+}
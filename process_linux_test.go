@@ -0,0 +1,13 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatTPGID(t *testing.T) {
+	assert.Equal(t, 4321, statTPGID("1234 (my (odd) sh) S 1 1234 1234 34816 4321 4194560 100 0"))
+	assert.Equal(t, -1, statTPGID("1234 (sh) S 1 1234 1234 34816"))
+	assert.Equal(t, -1, statTPGID("garbage"))
+}
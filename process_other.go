@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package terminal
+
+// processDirectory returns an empty string, as other systems, including macOS, have no way to read the
+// directory of another process without cgo. WorkingDirectory uses the directory reported by OSC 7 instead.
+func processDirectory(int) string {
+	return ""
+}
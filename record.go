@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+type castHeader struct {
+	Version   int   `json:"version"`
+	Width     uint  `json:"width"`
+	Height    uint  `json:"height"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+type recorder struct {
+	out   io.Writer
+	start time.Time
+}
+
+// StartRecording begins writing the terminal session to w in the asciinema v2 cast format.
+// Output received from the connection is recorded as "o" events and data sent using Write as "i" events.
+func (t *Terminal) StartRecording(w io.Writer) error {
+	t.recordLock.Lock()
+	defer t.recordLock.Unlock()
+	if t.recorder != nil {
+		return errors.New("terminal is already recording")
+	}
+
+	now := time.Now()
+	header, err := json.Marshal(castHeader{Version: 2, Width: t.screen.config.Columns, Height: t.screen.config.Rows,
+		Timestamp: now.Unix()})
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(append(header, '\n')); err != nil {
+		return err
+	}
+
+	t.recorder = &recorder{out: w, start: now}
+	return nil
+}
+
+// StopRecording ends a recording started with StartRecording.
+// The writer will be flushed and closed if it supports those operations.
+func (t *Terminal) StopRecording() error {
+	t.recordLock.Lock()
+	defer t.recordLock.Unlock()
+	if t.recorder == nil {
+		return nil
+	}
+
+	out := t.recorder.out
+	t.recorder = nil
+	if f, ok := out.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if c, ok := out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (t *Terminal) record(kind string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	t.recordLock.Lock()
+	defer t.recordLock.Unlock()
+	if t.recorder == nil {
+		return
+	}
+
+	elapsed := time.Since(t.recorder.start).Seconds()
+	event, err := json.Marshal([]interface{}{elapsed, kind, string(data)})
+	if err == nil {
+		_, err = t.recorder.out.Write(append(event, '\n'))
+	}
+	if err != nil {
+		fyne.LogError("failed to write recording", err)
+	}
+}
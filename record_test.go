@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminal_Recording(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 80
+	term.screen.config.Rows = 24
+	in := &bytes.Buffer{}
+	out := &bytes.Buffer{}
+	assert.Nil(t, term.StartRecording(out))
+	assert.NotNil(t, term.StartRecording(out))
+
+	_ = term.RunWithConnection(NopCloser(in), strings.NewReader("hello\r\n"))
+	_, _ = term.Write([]byte("ls\r"))
+	assert.Nil(t, term.StopRecording())
+	_, _ = term.Write([]byte("not recorded"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+
+	var header castHeader
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, 2, header.Version)
+	assert.Equal(t, uint(80), header.Width)
+	assert.Equal(t, uint(24), header.Height)
+
+	var event []interface{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, "o", event[1])
+	assert.Equal(t, "hello\r\n", event[2])
+	assert.Nil(t, json.Unmarshal([]byte(lines[2]), &event))
+	assert.Equal(t, "i", event[1])
+	assert.Equal(t, "ls\r", event[2])
+}
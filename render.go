@@ -1,21 +1,37 @@
 package terminal
 
 import (
+	"context"
 	"image/color"
+	"sort"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	widget2 "github.com/fyne-io/terminal/internal/widget"
 )
 
-const cursorWidth = 2
+const (
+	cursorWidth         = 2
+	cursorBlinkInterval = 500 * time.Millisecond
+)
 
 type render struct {
 	term *Terminal
 }
 
 func (r *render) Layout(s fyne.Size) {
-	r.term.content.Resize(s)
+	r.term.grid.Resize(s)
+	if r.term.background != nil {
+		r.term.background.Resize(s)
+	}
+	if r.term.backgroundImage != nil {
+		r.term.backgroundImage.Resize(s)
+	}
 }
 
 func (r *render) MinSize() fyne.Size {
@@ -25,8 +41,11 @@ func (r *render) MinSize() fyne.Size {
 func (r *render) Refresh() {
 	r.moveCursor()
 	r.term.refreshCursor()
+	r.term.ensureCursorBlinking()
+	r.term.refreshImages()
+	r.term.refreshBackground()
 
-	r.term.content.Refresh()
+	r.term.grid.Refresh()
 }
 
 func (r *render) BackgroundColor() color.Color {
@@ -34,35 +53,385 @@ func (r *render) BackgroundColor() color.Color {
 }
 
 func (r *render) Objects() []fyne.CanvasObject {
-	return []fyne.CanvasObject{r.term.content, r.term.cursor}
+	var objects []fyne.CanvasObject
+	if r.term.background != nil {
+		objects = append(objects, r.term.background)
+	}
+	if r.term.backgroundImage != nil {
+		objects = append(objects, r.term.backgroundImage)
+	}
+	if r.term.focused && r.term.screen.cursorShape == CursorShapeBlock {
+		return append(objects, r.term.cursor, r.term.imagesBelow, r.term.grid, r.term.imageLayer, r.term.linkUnderline) // draw the block behind the text
+	}
+	return append(objects, r.term.imagesBelow, r.term.grid, r.term.imageLayer, r.term.cursor, r.term.linkUnderline)
 }
 
 func (r *render) Destroy() {
+	r.term.stopCursorBlink()
 }
 
 func (r *render) moveCursor() {
 	cell := r.term.guessCellSize()
-	r.term.cursor.Move(fyne.NewPos(cell.Width*float32(r.term.cursorCol), cell.Height*float32(r.term.cursorRow)))
+	col := r.term.screen.cursorCol
+	if cols := int(r.term.screen.config.Columns); cols > 0 && col >= cols {
+		col = cols - 1 // waiting to wrap, so show the cursor on the last column
+	}
+	pos := fyne.NewPos(cell.Width*float32(col), cell.Height*float32(r.term.screen.cursorRow+r.term.scrollOffset))
+	if r.term.focused && r.term.screen.cursorShape == CursorShapeUnderline {
+		pos.Y += cell.Height - cursorWidth
+	}
+	r.term.cursor.Move(pos)
 }
 
 func (t *Terminal) refreshCursor() {
-	t.cursor.Hidden = !t.focused || t.cursorHidden
+	if t.cursor == nil { // not yet rendered
+		return
+	}
+
+	t.cursor.Hidden = t.screen.cursorHidden || (!t.focused && !t.cursorHollowUnfocused) || (t.focused && t.cursorBlinkOff) ||
+		(t.scrollOffset > 0 && t.screen.cursorRow+t.scrollOffset >= int(t.screen.config.Rows)) // scrolled out of view
+	cursorColor := theme.PrimaryColor()
 	if t.bell {
-		t.cursor.FillColor = theme.ErrorColor()
+		cursorColor = theme.ErrorColor()
+	}
+
+	cell := t.guessCellSize()
+	if t.focused {
+		t.cursor.FillColor = cursorColor
+		t.cursor.StrokeWidth = 0
+		switch t.screen.cursorShape {
+		case CursorShapeBlock:
+			t.cursor.Resize(cell)
+		case CursorShapeUnderline:
+			t.cursor.Resize(fyne.NewSize(cell.Width, cursorWidth))
+		default:
+			t.cursor.Resize(fyne.NewSize(cursorWidth, cell.Height))
+		}
 	} else {
-		t.cursor.FillColor = theme.PrimaryColor()
+		t.cursor.FillColor = color.Transparent
+		t.cursor.StrokeColor = cursorColor
+		t.cursor.StrokeWidth = 1
+		t.cursor.Resize(cell)
 	}
-	t.cursor.Resize(fyne.NewSize(cursorWidth, t.guessCellSize().Height))
 	t.cursor.Refresh()
 }
 
+// SetCursorBlink sets whether the cursor is allowed to blink. The default is true.
+// Applications choose a blinking or steady cursor using DECSCUSR, if this is false the cursor never blinks.
+func (t *Terminal) SetCursorBlink(blink bool) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.cursorBlinkEnabled = blink
+	t.ensureCursorBlinking()
+	t.refreshCursor()
+}
+
+// SetCursorBlinkRate sets how long the cursor stays on and off while blinking.
+// A rate of 0 stops the cursor from blinking.
+func (t *Terminal) SetCursorBlinkRate(d time.Duration) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.cursorBlinkRate = d
+	t.ensureCursorBlinking()
+	t.refreshCursor()
+}
+
+// SetCursorShape sets the shape of the text cursor, the default is CursorShapeCaret.
+// Applications may change the shape using DECSCUSR, resetting it will return to this shape.
+func (t *Terminal) SetCursorShape(shape CursorShape) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.screen.cursorShape = shape
+	t.screen.defaultCursorShape = shape
+	t.Refresh()
+}
+
+// SetBackgroundImage sets an image to draw behind the terminal content, filling the terminal using mode.
+// Cells without a background colour show the image, faded by opacity from 0 (invisible) to 1.
+// Pass a nil resource to remove the image.
+func (t *Terminal) SetBackgroundImage(res fyne.Resource, mode canvas.ImageFill, opacity float32) {
+	if res == nil {
+		t.backgroundImage = nil
+		t.Refresh()
+		return
+	}
+
+	if opacity < 0 {
+		opacity = 0
+	} else if opacity > 1 {
+		opacity = 1
+	}
+	img := canvas.NewImageFromResource(res)
+	img.FillMode = mode
+	img.Translucency = float64(1 - opacity)
+	img.Resize(t.Size())
+	t.backgroundImage = img
+	t.Refresh()
+}
+
+// SetBackgroundColor sets the colour drawn behind cells that have no background colour set by the
+// application, recolouring the existing content. Pass nil to leave these cells transparent, the default.
+func (t *Terminal) SetBackgroundColor(c color.Color) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.grid.DefaultBackground = c
+	t.grid.Refresh()
+}
+
+// SetFallbackFonts sets fonts to use, in order, for characters that the monospace font does not have,
+// such as Powerline symbols or Nerd Font icons. Call with no fonts to remove them.
+func (t *Terminal) SetFallbackFonts(fonts ...fyne.Resource) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.grid.FallbackFonts = fonts
+	t.grid.Refresh()
+}
+
+// SetLigaturesEnabled sets whether runs of symbols with the same colour are drawn together, so that
+// programming fonts can show ligatures such as "->" or "!=". Each character still takes one cell.
+// The default is false, drawing every cell separately.
+func (t *Terminal) SetLigaturesEnabled(enabled bool) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.grid.Ligatures = enabled
+	t.grid.Refresh()
+}
+
+// SetForceColors draws all content using the given text and background colours, ignoring the colours
+// requested by the application, for example to provide a high contrast mode. Selected text is shown
+// with the colours swapped. Pass nil colours to return to normal.
+func (t *Terminal) SetForceColors(fg, bg color.Color) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.grid.ForcedForeground, t.grid.ForcedBackground = fg, bg
+	t.grid.Refresh()
+}
+
+// SetTextBlinkEnabled sets whether text with the blink attribute should blink.
+// When disabled the text is drawn steadily, which some users find more comfortable. The default is true.
+func (t *Terminal) SetTextBlinkEnabled(blink bool) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.grid.BlinkDisabled = !blink
+	t.grid.Refresh()
+}
+
+// SetBackgroundOpacity draws the theme background colour behind the terminal content with the given
+// alpha, from 0 (transparent) to 1 (opaque), so that whatever is behind the terminal can show through.
+// Text and cells with a background colour set by the application are not affected.
+func (t *Terminal) SetBackgroundOpacity(alpha float32) {
+	if alpha < 0 {
+		alpha = 0
+	} else if alpha > 1 {
+		alpha = 1
+	}
+	t.backgroundOpacity = alpha
+	if t.background == nil {
+		t.background = canvas.NewRectangle(color.Transparent)
+		t.background.Resize(t.Size())
+	}
+	t.refreshBackground()
+	t.Refresh()
+}
+
+func (t *Terminal) refreshBackground() {
+	if t.background == nil {
+		return
+	}
+
+	r, g, b, _ := theme.BackgroundColor().RGBA()
+	t.background.FillColor = color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8),
+		A: uint8(t.backgroundOpacity * 0xff)}
+	t.background.Refresh()
+}
+
+// SetUnfocusedCursorStyle sets whether the cursor is drawn as a hollow block when the terminal is not focused.
+// If hollow is false the cursor will be hidden when focus is lost. The default is true.
+func (t *Terminal) SetUnfocusedCursorStyle(hollow bool) {
+	t.cursorHollowUnfocused = hollow
+	t.refreshCursor()
+}
+
 // CreateRenderer requests a new renderer for this terminal (just a wrapper around the TextGrid)
 func (t *Terminal) CreateRenderer() fyne.WidgetRenderer {
 	t.cursor = canvas.NewRectangle(theme.PrimaryColor())
 	t.cursor.Hidden = true
 	t.cursor.Resize(fyne.NewSize(cursorWidth, t.guessCellSize().Height))
+	t.linkUnderline = container.NewWithoutLayout()
+	t.imageLayer = container.NewWithoutLayout()
+	t.imagesBelow = container.NewWithoutLayout()
 
 	r := &render{term: t}
-	t.cursorMoved = r.moveCursor
+	t.screen.cursorMoved = r.moveCursor
 	return r
 }
+
+// syncGrid copies the rows that have changed on the screen into the grid that draws them.
+// If the view is scrolled back all of its rows are copied again. The state lock must be held.
+func (t *Terminal) syncGrid() {
+	if t.screen.altScreen {
+		t.scrollOffset = 0 // the alternate screen has no scrollback
+	}
+	if t.scrollOffset > len(t.screen.scrollback) {
+		t.scrollOffset = len(t.screen.scrollback)
+	}
+	dirty := t.screen.content.takeDirtyRows()
+	if t.scrollOffset != 0 || t.viewOffset != 0 {
+		t.viewOffset = t.scrollOffset
+		view := t.viewRows()
+		old := len(t.grid.Rows)
+		t.grid.Rows = make([]widget.TextGridRow, len(view))
+		for i, row := range view {
+			t.grid.Rows[i] = t.textGridRow(row)
+		}
+		if len(view) > old {
+			old = len(view)
+		}
+		t.grid.MarkRowsDirty(0, old-1)
+		return
+	}
+
+	rows := t.screen.content.Rows
+	if len(rows) < len(t.grid.Rows) {
+		t.grid.MarkRowsDirty(len(rows), len(t.grid.Rows)-1)
+		t.grid.Rows = t.grid.Rows[:len(rows)]
+	}
+	for i := len(t.grid.Rows); i < len(rows); i++ {
+		t.grid.SetRow(i, t.textGridRow(rows[i]))
+	}
+	for i := range dirty {
+		if i < len(rows) {
+			t.grid.SetRow(i, t.textGridRow(rows[i]))
+		}
+	}
+}
+
+// textGridRow returns a row of the screen in the form drawn by the grid.
+func (t *Terminal) textGridRow(row gridRow) widget.TextGridRow {
+	cells := make([]widget.TextGridCell, len(row.Cells))
+	for i, cell := range row.Cells {
+		cells[i] = t.textGridCell(cell)
+	}
+	return widget.TextGridRow{Cells: cells}
+}
+
+// textGridCell returns a cell of the screen in the form drawn by the grid.
+func (t *Terminal) textGridCell(cell Cell) widget.TextGridCell {
+	r := cell.Rune
+	if r == wideCharPadding {
+		r = widget2.WideCharPadding
+	}
+	if cell.isBlank() && cell.Style == (CellStyle{}) {
+		return widget.TextGridCell{Rune: r} // nothing has been printed here
+	}
+	return widget.TextGridCell{Rune: r, Style: t.textGridStyle(cell.Style)}
+}
+
+// textGridStyle returns the grid style that draws a cell with the given attributes.
+func (t *Terminal) textGridStyle(s CellStyle) widget.TextGridStyle {
+	if s.Blinking || s.Protected || s.Overline || s.Concealed {
+		style := widget2.NewTermTextGridStyle(s.Foreground, s.Background, t.highlightBitMask, s.Blinking)
+		style.(*widget2.TermTextGridStyle).Protected = s.Protected
+		style.(*widget2.TermTextGridStyle).Overline = s.Overline
+		style.(*widget2.TermTextGridStyle).Concealed = s.Concealed
+		return style
+	}
+	return &widget.CustomTextGridStyle{FGColor: s.Foreground, BGColor: s.Background}
+}
+
+// refreshImages positions the image overlay to match the content and scroll position.
+func (t *Terminal) refreshImages() {
+	if t.imageLayer == nil { // not yet rendered
+		return
+	}
+
+	cell := t.guessCellSize()
+	sort.SliceStable(t.screen.images, func(i, j int) bool {
+		return t.screen.images[i].z < t.screen.images[j].z
+	})
+	objects := make(map[*inlineImage]*canvas.Image, len(t.screen.images))
+	var above, below []fyne.CanvasObject
+	for _, img := range t.screen.images {
+		obj := t.imageObjects[img]
+		if obj == nil {
+			obj = canvas.NewImageFromImage(img.img)
+			obj.FillMode = canvas.ImageFillStretch // the cell size already matches the requested shape
+		}
+		objects[img] = obj
+		obj.Resize(fyne.NewSize(cell.Width*float32(img.width), cell.Height*float32(img.height)))
+		obj.Move(fyne.NewPos(cell.Width*float32(img.col), cell.Height*float32(img.row+t.scrollOffset)))
+		if img.z < 0 {
+			below = append(below, obj)
+		} else {
+			above = append(above, obj)
+		}
+	}
+	t.imageObjects = objects // images that have been removed are forgotten
+	t.imageLayer.Objects = above
+	t.imageLayer.Refresh()
+	t.imagesBelow.Objects = below
+	t.imagesBelow.Refresh()
+}
+
+// flashBell shows the bell for a moment, it is called on a new goroutine when the bell rings.
+func (t *Terminal) flashBell() {
+	if t.bellHandler != nil {
+		t.bellHandler()
+	}
+	t.showBell(true)
+	time.Sleep(time.Millisecond * 300)
+	t.showBell(false)
+}
+
+// showBell turns the bell colouring of the cursor, and the visual bell if it is on, on or off.
+func (t *Terminal) showBell(ring bool) {
+	t.screen.stateLock.Lock()
+	t.bell = ring
+	t.grid.Inverted = ring && t.visualBell
+	t.screen.stateLock.Unlock()
+
+	t.scheduleRefresh()
+}
+
+// ensureCursorBlinking starts or stops the cursor blink timer to match the current cursor settings.
+func (t *Terminal) ensureCursorBlinking() {
+	shouldBlink := t.cursorBlinkEnabled && t.cursorBlinkRate > 0 && t.screen.cursorBlinks && t.focused && !t.screen.cursorHidden
+	if shouldBlink && t.cursorBlinkCancel == nil {
+		t.startCursorBlink()
+	} else if !shouldBlink && t.cursorBlinkCancel != nil {
+		t.stopCursorBlink()
+	}
+}
+
+func (t *Terminal) startCursorBlink() {
+	var blinkContext context.Context
+	blinkContext, t.cursorBlinkCancel = context.WithCancel(context.Background())
+	rate := t.cursorBlinkRate
+	go func() {
+		for {
+			select {
+			case <-blinkContext.Done():
+				return
+			case <-time.After(rate):
+			}
+
+			t.screen.stateLock.Lock()
+			if blinkContext.Err() == nil { // not stopped while we waited for the lock
+				t.cursorBlinkOff = !t.cursorBlinkOff
+				t.refreshCursor()
+			}
+			rate = t.cursorBlinkRate // read the rate each time so that changes apply from the next toggle
+			t.screen.stateLock.Unlock()
+		}
+	}()
+}
+
+func (t *Terminal) stopCursorBlink() {
+	if t.cursorBlinkCancel == nil {
+		return
+	}
+
+	t.cursorBlinkCancel()
+	t.cursorBlinkCancel = nil
+	t.cursorBlinkOff = false
+}
@@ -0,0 +1,153 @@
+package terminal
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminal_UnfocusedCursor(t *testing.T) {
+	term := New()
+	term.Refresh() // creates the renderer and cursor
+	assert.False(t, term.cursor.Hidden)
+	assert.Equal(t, color.Transparent, term.cursor.FillColor)
+	assert.Equal(t, theme.PrimaryColor(), term.cursor.StrokeColor)
+
+	term.FocusGained()
+	assert.False(t, term.cursor.Hidden)
+	assert.Equal(t, theme.PrimaryColor(), term.cursor.FillColor)
+	assert.Equal(t, float32(cursorWidth), term.cursor.Size().Width)
+
+	term.SetUnfocusedCursorStyle(false)
+	term.FocusLost()
+	assert.True(t, term.cursor.Hidden)
+}
+
+func TestTerminal_CursorShape(t *testing.T) {
+	term := New()
+	term.FocusGained()
+	cell := term.guessCellSize()
+
+	term.handleOutput([]byte(esc("[4 q")))
+	assert.Equal(t, CursorShapeUnderline, term.screen.cursorShape)
+	term.Refresh()
+	assert.Equal(t, fyne.NewSize(cell.Width, cursorWidth), term.cursor.Size())
+	assert.Equal(t, cell.Height-cursorWidth, term.cursor.Position().Y)
+
+	term.handleOutput([]byte(esc("[2 q")))
+	assert.Equal(t, CursorShapeBlock, term.screen.cursorShape)
+	term.Refresh()
+	assert.Equal(t, cell, term.cursor.Size())
+
+	term.handleOutput([]byte(esc("[6 q")))
+	assert.Equal(t, CursorShapeCaret, term.screen.cursorShape)
+
+	term.SetCursorShape(CursorShapeUnderline)
+	term.handleOutput([]byte(esc("[2 q") + esc("[0 q")))
+	assert.Equal(t, CursorShapeUnderline, term.screen.cursorShape)
+}
+
+func TestTerminal_CursorBlink(t *testing.T) {
+	term := New()
+	term.FocusGained()
+	assert.Nil(t, term.cursorBlinkCancel)
+
+	term.handleOutput([]byte(esc("[1 q")))
+	assert.True(t, term.screen.cursorBlinks)
+	term.Refresh()
+	assert.NotNil(t, term.cursorBlinkCancel)
+
+	term.handleOutput([]byte(esc("[2 q")))
+	assert.False(t, term.screen.cursorBlinks)
+	term.Refresh()
+	assert.Nil(t, term.cursorBlinkCancel)
+
+	term.handleOutput([]byte(esc("[5 q")))
+	term.SetCursorBlink(false)
+	term.Refresh()
+	assert.Nil(t, term.cursorBlinkCancel)
+	assert.False(t, term.cursor.Hidden)
+}
+
+func TestTerminal_CursorBlinkRate(t *testing.T) {
+	term := New()
+	term.FocusGained()
+	term.handleOutput([]byte(esc("[1 q")))
+	term.SetCursorBlinkRate(time.Millisecond * 10)
+	assert.NotNil(t, term.cursorBlinkCancel)
+	for i := 0; i < 10; i++ {
+		term.SetCursorBlinkRate(time.Millisecond * time.Duration(i+1)) // changed while blinking
+		time.Sleep(time.Millisecond)
+	}
+
+	term.SetCursorBlinkRate(0)
+	assert.Nil(t, term.cursorBlinkCancel)
+	assert.False(t, term.cursor.Hidden)
+}
+
+func TestTerminal_CursorBlinkDuringOutput(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 2
+	term.screen.scrollBottom = 1
+	term.FocusGained()
+	term.SetCursorBlinkRate(time.Millisecond)
+	term.handleOutput([]byte(esc("[1 q")))
+	term.Refresh()
+
+	out := strings.Repeat("hello\r\n"+esc("[1;3H")+esc("[?25l")+esc("[?25h"), 500)
+	_ = term.RunWithConnection(NopCloser(&bytes.Buffer{}), strings.NewReader(out))
+	term.SetCursorBlinkRate(0)
+	assert.False(t, term.cursor.Hidden)
+}
+
+func TestTerminal_SetBackgroundImage(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(100, 80))
+	r := test.WidgetRenderer(term)
+	assert.Equal(t, term.imagesBelow, r.Objects()[0])
+
+	term.SetBackgroundImage(theme.FyneLogo(), canvas.ImageFillStretch, 0.25)
+	bg, ok := r.Objects()[0].(*canvas.Image)
+	assert.True(t, ok)
+	assert.Equal(t, 0.75, bg.Translucency)
+	assert.Equal(t, canvas.ImageFillStretch, bg.FillMode)
+	assert.Equal(t, fyne.NewSize(100, 80), bg.Size())
+
+	term.SetBackgroundImage(nil, canvas.ImageFillStretch, 1)
+	assert.Equal(t, term.imagesBelow, r.Objects()[0])
+}
+
+func TestTerminal_SetBackgroundOpacity(t *testing.T) {
+	term := New()
+	r := test.WidgetRenderer(term)
+
+	term.SetBackgroundOpacity(0.5)
+	bg, ok := r.Objects()[0].(*canvas.Rectangle)
+	assert.True(t, ok)
+	assert.Equal(t, uint8(127), bg.FillColor.(color.NRGBA).A)
+
+	term.SetBackgroundOpacity(2)
+	assert.Equal(t, uint8(0xff), bg.FillColor.(color.NRGBA).A)
+}
+
+func TestTerminal_SetBackgroundColor(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 1
+	term.handleOutput([]byte("Hello"))
+	bg := &color.RGBA{B: 0x80, A: 0xff}
+
+	term.SetBackgroundColor(bg)
+	assert.Equal(t, bg, term.grid.DefaultBackground)
+	assert.Nil(t, term.screen.content.Rows[0].Cells[0].Style.Background) // resolved when drawn
+
+	term.SetBackgroundColor(nil)
+	assert.Nil(t, term.grid.DefaultBackground)
+}
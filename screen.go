@@ -0,0 +1,452 @@
+package terminal
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"sync"
+)
+
+const (
+	defaultCellWidth  = 8  // the width, in pixels, used to size images on a screen that is not drawn
+	defaultCellHeight = 16 // the height, in pixels, used to size images on a screen that is not drawn
+)
+
+type charSet int
+
+const (
+	charSetANSII charSet = iota
+	charSetDECSpecialGraphics
+	charSetAlternate // the United Kingdom national replacement character set
+	charSetDutch
+	charSetFinnish
+	charSetFrench
+	charSetFrenchCanadian
+	charSetGerman
+	charSetItalian
+	charSetNorwegianDanish
+	charSetSpanish
+	charSetSwedish
+	charSetSwiss
+)
+
+// CursorShape describes how the text cursor is drawn.
+type CursorShape string
+
+const (
+	// CursorShapeBlock draws the cursor as a block filling the cell.
+	CursorShapeBlock CursorShape = "block"
+	// CursorShapeCaret draws the cursor as a thin vertical bar at the left of the cell.
+	CursorShapeCaret CursorShape = "caret"
+	// CursorShapeUnderline draws the cursor as a thin line along the bottom of the cell.
+	CursorShapeUnderline CursorShape = "underline"
+)
+
+// mouseTracking is the mouse reporting mode that the program has asked for.
+type mouseTracking int
+
+const (
+	mouseTrackingOff    mouseTracking = iota
+	mouseTrackingX10                  // presses are reported (9)
+	mouseTrackingNormal               // presses and releases are reported (1000)
+)
+
+// savedCursor is the cursor state saved by DECSC and restored by DECRC.
+type savedCursor struct {
+	row, col                  int // a column past the last one means a wrap is pending
+	fg, bg                    color.Color
+	bold, blinking, protected bool
+	overline, concealed       bool
+	g0Charset, g1Charset      charSet
+	useG1CharSet, originMode  bool
+}
+
+// Screen is the terminal emulation, it parses the output of a program and keeps the resulting
+// grid of cells, the cursor and the modes that the program has set. It does not draw anything,
+// so it can be used on its own, for example to render a session on a server or to drive a remote
+// user interface, without a Fyne app or driver. A Terminal shows a Screen in a widget.
+type Screen struct {
+	config       Config
+	listenerLock sync.Mutex
+	listeners    []chan Config
+	directory    string // the last working directory reported using OSC 7
+
+	content                  *grid
+	bold, debug              bool
+	boldIsBright             bool
+	currentFG, currentBG     color.Color
+	cursorRow, cursorCol     int
+	savedCursor              savedCursor // saved by DECSC (ESC 7)
+	scoSavedRow, scoSavedCol int         // saved by SCOSC (CSI s), separately from DECSC as in xterm
+	scrollTop, scrollBottom  int
+
+	cursorHidden          bool
+	applicationCursorKeys bool // cursor keys send SS3 rather than CSI sequences (DECCKM)
+	altScreen             bool
+	mainRows              []gridRow // the main screen content while the alternate screen is shown
+	altRows               []gridRow // the alternate screen content while the main screen is shown
+	scrollback            []gridRow // lines that scrolled off the top of the main screen, oldest first
+	cursorShape           CursorShape
+	defaultCursorShape    CursorShape
+	cursorBlinks          bool // the application requested a blinking cursor
+
+	mouseTracking     mouseTracking
+	mouseUTF8         bool // the extended mouse report encodings (1005 and 1006)
+	mouseSGR          bool
+	g0Charset         charSet
+	g1Charset         charSet
+	specialGraphics   map[rune]rune // replacements for the DEC special graphics, if set
+	useG1CharSet      bool
+	images            []*inlineImage
+	mainImages        []*inlineImage // the main screen images while the alternate screen is shown
+	iTerm2Handler     func(string)
+	kittyImages       map[uint32]image.Image // kitty graphics images that were transmitted with an id
+	kittyTransfer     *kittyTransfer
+	newLineMode       bool // new line mode or line feed mode
+	insertMode        bool // printed characters move the rest of the line right (IRM)
+	originMode        bool // cursor addressing is relative to, and bounded by, the scroll region
+	autoWrap          bool // print on the next line when the cursor passes the last column
+	reverseWrap       bool // backspace at column 0 moves to the end of the previous line
+	tabWidth          int
+	backarrowSendsBS  bool // the Backspace key sends BS instead of DEL (DECBKM)
+	allowColumnSwitch bool
+
+	bracketedPasteMode bool
+	state              *parseState
+	leftOver           []byte // the start of a sequence that was split between calls to Write
+	maxStringLength    int    // the longest OSC, APC or DCS string accepted, 0 for no limit
+	maxEscapeLength    int    // the longest control sequence parameter string accepted, 0 for no limit
+	c1Controls         bool   // 8-bit C1 control characters are recognised
+	titleSetHex        bool   // titles set by the program are hex encoded
+	titleQueryHex      bool   // titles reported to the program are hex encoded
+	blinking           bool
+	overline           bool
+	concealed          bool
+	protected          bool // characters are protected from selective erase (DECSCA)
+	printData          []byte
+	printer            Printer
+	csiHandlers        map[string]func(string)
+	dcsHandlers        map[string]func(string)
+
+	eventListeners []chan TerminalEvent // guarded by listenerLock
+
+	// replies receives the answers to requests such as device attributes, it is the input of the program
+	replies io.Writer
+
+	// These are set by a Terminal showing the screen, and are nil when it is used on its own.
+	cursorMoved   func()
+	bellRung      func()                         // called on a new goroutine when the bell rings
+	resize        func(rows, cols uint)          // resizes the screen for DECCOLM
+	scrolledOff   func()                         // a line has moved into the scrollback
+	cellSize      func() (width, height float32) // the size of each cell, used to size images
+	defaultColors func() (fg, bg color.Color)    // the colours that reverse video uses when none is set
+	runAPCHandler func(APCHandler, string)       // calls a handler registered with RegisterAPCHandler
+
+	// stateLock guards the screen and cursor against the goroutines that change or read them.
+	// It is held while output is handled, so handlers and callbacks run then must not take it.
+	stateLock sync.Mutex
+}
+
+// NewScreen creates a screen with the given number of rows and columns that is not shown in a widget.
+func NewScreen(rows, cols uint) *Screen {
+	s := newScreen()
+	s.setSize(rows, cols)
+	return s
+}
+
+func newScreen() *Screen {
+	return &Screen{
+		content:            &grid{},
+		autoWrap:           true,
+		boldIsBright:       true,
+		cursorShape:        CursorShapeCaret,
+		defaultCursorShape: CursorShapeCaret,
+		tabWidth:           defaultTabWidth,
+		maxStringLength:    maxStringLength,
+		maxEscapeLength:    maxEscapeLength,
+	}
+}
+
+// SetReplyWriter sets where the replies to requests such as device attributes and cursor position
+// reports are written, normally the input of the program that produces the output.
+func (s *Screen) SetReplyWriter(w io.Writer) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	s.replies = w
+}
+
+// reply sends the answer to a request made by the program.
+func (s *Screen) reply(b []byte) {
+	if s.replies != nil {
+		_, _ = s.replies.Write(b)
+	}
+}
+
+// Write processes output from a program, updating the screen. It always consumes all of p.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	data := append(s.leftOver, p...)
+	s.leftOver = append([]byte{}, s.handleOutput(data)...)
+	return len(p), nil
+}
+
+// SetGridSize changes the number of rows and columns of the screen.
+func (s *Screen) SetGridSize(rows, cols uint) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	s.resizeGrid(rows, cols)
+}
+
+// resizeGrid changes the size of the screen for DECCOLM or SetGridSize, the state lock must be held.
+func (s *Screen) resizeGrid(rows, cols uint) {
+	if s.resize != nil {
+		s.resize(rows, cols)
+		return
+	}
+	if s.config.Columns == cols && s.config.Rows == rows {
+		return
+	}
+	s.setSize(rows, cols)
+	s.onConfigure()
+}
+
+// setSize records the number of rows and columns, keeping a scroll region that covered the screen
+// covering it, the state lock must be held.
+func (s *Screen) setSize(rows, cols uint) {
+	oldRows := int(s.config.Rows)
+	s.config.Columns, s.config.Rows = cols, rows
+	if s.scrollBottom == 0 || s.scrollBottom == oldRows-1 {
+		s.scrollBottom = int(s.config.Rows) - 1
+	}
+	s.sendEvent(TerminalEvent{Type: EventResize, Rows: rows, Cols: cols})
+}
+
+// GridSize returns the number of rows and columns of the screen.
+func (s *Screen) GridSize() (rows, cols uint) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.config.Rows, s.config.Columns
+}
+
+// Text returns the content of the screen as a single string joined with `\n` (no style information).
+func (s *Screen) Text() string {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.content.Text()
+}
+
+// Line returns a copy of the cells in a row, counted from the oldest line of scrollback followed
+// by the screen, or nil if the row is outside the buffer.
+// The cell after a double width character, which the character covers, is a zero Cell.
+func (s *Screen) Line(row int) []Cell {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	line, ok := s.bufferRow(row)
+	if !ok {
+		return nil
+	}
+
+	cells := make([]Cell, len(line.Cells))
+	for i, cell := range line.Cells {
+		cells[i] = cell.reported()
+	}
+	return cells
+}
+
+// Cell returns the rune and style of the cell at the given row and column, with rows counted as for Line.
+// The final return value is false if the position is outside the buffer.
+// The cell after a double width character, which the character covers, has a 0 rune and no style.
+func (s *Screen) Cell(row, col int) (rune, CellStyle, bool) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	line, ok := s.bufferRow(row)
+	if !ok || col < 0 || col >= len(line.Cells) {
+		return 0, CellStyle{}, false
+	}
+
+	cell := line.Cells[col].reported()
+	return cell.Rune, cell.Style, true
+}
+
+// bufferRow returns a row of the scrollback followed by the screen, without copying them.
+func (s *Screen) bufferRow(row int) (gridRow, bool) {
+	if row < 0 {
+		return gridRow{}, false
+	}
+	if row < len(s.scrollback) {
+		return s.scrollback[row], true
+	}
+	if row -= len(s.scrollback); row >= len(s.content.Rows) {
+		return gridRow{}, false
+	}
+	return s.content.Rows[row], true
+}
+
+// CursorPosition returns the row and column of the cursor, counting from 0.
+func (s *Screen) CursorPosition() (row, col int) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.cursorRow, s.cursorCol
+}
+
+// ScrollRegion returns the first and last rows, counted from 0, of the area that scrolls as lines are added.
+// Unless a region has been set this is the whole screen.
+func (s *Screen) ScrollRegion() (top, bottom int) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.scrollTop, s.scrollBottom
+}
+
+// SetScrollRegion sets the first and last rows, counted from 0, of the area that scrolls as lines are added,
+// in the same way as the DECSTBM escape sequence. Rows outside the region stay in place, which can be used
+// to keep status lines at the top or bottom of the screen. The cursor is moved to the home position.
+// A region that does not contain at least two rows is ignored.
+func (s *Screen) SetScrollRegion(top, bottom int) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	s.setScrollRegion(top, bottom)
+}
+
+// CursorVisible returns true unless the program has hidden the cursor.
+func (s *Screen) CursorVisible() bool {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return !s.cursorHidden
+}
+
+// CurrentStyle returns the attributes that will be used for the next characters printed.
+func (s *Screen) CurrentStyle() CellStyle {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.currentStyle()
+}
+
+// Title returns the title set by the program.
+func (s *Screen) Title() string {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.config.Title
+}
+
+// OnAltScreen returns true if the program has switched to the alternate screen.
+func (s *Screen) OnAltScreen() bool {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.altScreen
+}
+
+// SetTitle sets the title and notifies listeners, as if the application had set it using OSC 2.
+func (s *Screen) SetTitle(title string) {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	s.setTitle(title)
+}
+
+// AddListener registers a new outgoing channel that will have our Config sent each time it changes.
+func (s *Screen) AddListener(listener chan Config) {
+	s.listenerLock.Lock()
+	defer s.listenerLock.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+// RemoveListener de-registers a Config channel and closes it
+func (s *Screen) RemoveListener(listener chan Config) {
+	s.listenerLock.Lock()
+	defer s.listenerLock.Unlock()
+
+	for i, l := range s.listeners {
+		if l == listener {
+			if i < len(s.listeners)-1 {
+				s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+			} else {
+				s.listeners = s.listeners[:i]
+			}
+			close(l)
+			return
+		}
+	}
+}
+
+func (s *Screen) onConfigure() {
+	s.listenerLock.Lock()
+	for _, l := range s.listeners {
+		select {
+		case l <- s.config:
+		default:
+			// channel blocked, might be closed
+		}
+	}
+	s.listenerLock.Unlock()
+}
+
+// SetDebug turns on output about terminal codes and other errors if the parameter is `true`.
+func (s *Screen) SetDebug(debug bool) {
+	s.debug = debug
+}
+
+// SetAllowColumnModeSwitch sets whether applications may switch between 80 and 132 columns using DECCOLM.
+// The default is false, so that the number of columns follows the size of the widget.
+func (s *Screen) SetAllowColumnModeSwitch(allow bool) {
+	s.allowColumnSwitch = allow
+}
+
+// SetTabWidth sets the number of columns between tab stops, the default is 8.
+// The width is limited to between 1 and 32 and applies to output received after it is set.
+func (s *Screen) SetTabWidth(n int) {
+	if n < 1 {
+		n = 1
+	} else if n > maxTabWidth {
+		n = maxTabWidth
+	}
+	s.tabWidth = n
+}
+
+// SetSpecialGraphicsMap replaces some or all of the characters shown for the DEC special graphics
+// character set, which programs use for line drawing. Each key is the ASCII character sent by the program
+// and the value is the character to show, characters that are not in the map keep their default.
+// Pass nil to return to the default Unicode characters.
+func (s *Screen) SetSpecialGraphicsMap(m map[rune]rune) {
+	s.specialGraphics = m
+}
+
+// SetASCIIBoxDrawing sets whether the DEC special graphics are shown using ASCII approximations,
+// such as '+', '-' and '|' for boxes, instead of Unicode line drawing characters.
+// This is useful with fonts that draw the Unicode characters poorly. It replaces any map set using
+// SetSpecialGraphicsMap, and turning it off returns to the default characters.
+func (s *Screen) SetASCIIBoxDrawing(ascii bool) {
+	if ascii {
+		s.specialGraphics = asciiSpecialGraphics
+	} else {
+		s.specialGraphics = nil
+	}
+}
+
+// SetMaxStringLength sets the longest OSC, APC or DCS string, in bytes, that will be accepted.
+// A longer string is discarded, up to its terminator, so that a program that never terminates
+// one cannot use unlimited memory. The default is 1MB, and 0 removes the limit.
+func (s *Screen) SetMaxStringLength(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.maxStringLength = n
+}
+
+// SetMaxControlSequenceLength sets the longest parameter string, in bytes, of a control sequence such as CSI.
+// A longer sequence is abandoned and printed as text, as it is most likely not meant to be a sequence.
+// The default is 64, and 0 removes the limit.
+func (s *Screen) SetMaxControlSequenceLength(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.maxEscapeLength = n
+}
+
+// SetC1Controls sets whether the 8-bit C1 control characters, 0x80 to 0x9f, are recognised as the
+// equivalent escape sequence, for example 0x9b as CSI, for programs that use an 8-bit encoding.
+// The default is false, as in UTF-8 output these are shown as text. The 8-bit string terminator
+// (0x9c) always ends an OSC, APC or DCS string.
+func (s *Screen) SetC1Controls(enabled bool) {
+	s.c1Controls = enabled
+}
@@ -0,0 +1,49 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScreen(t *testing.T) {
+	s := NewScreen(3, 10)
+	rows, cols := s.GridSize()
+	assert.Equal(t, uint(3), rows)
+	assert.Equal(t, uint(10), cols)
+
+	_, _ = s.Write([]byte("\x1b]2;Headless\x07\x1b[3"))
+	_, _ = s.Write([]byte("1mhi\xe2\x82"))
+	n, err := s.Write([]byte("\xac"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, "hi€", s.Text())
+	assert.Equal(t, "Headless", s.Title())
+	r, style, ok := s.Cell(0, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 'h', r)
+	assert.Equal(t, basicColors[1], style.Foreground)
+	row, col := s.CursorPosition()
+	assert.Equal(t, 0, row)
+	assert.Equal(t, 3, col)
+
+	s.SetGridSize(5, 20)
+	rows, cols = s.GridSize()
+	assert.Equal(t, uint(5), rows)
+	assert.Equal(t, uint(20), cols)
+}
+
+func TestScreen_WideChar(t *testing.T) {
+	s := NewScreen(1, 4)
+	_, _ = s.Write([]byte("世a"))
+
+	r, _, ok := s.Cell(0, 0)
+	assert.True(t, ok)
+	assert.Equal(t, '世', r)
+	r, _, ok = s.Cell(0, 1)
+	assert.True(t, ok)
+	assert.Equal(t, rune(0), r) // covered by the wide character
+
+	assert.Equal(t, []Cell{{Rune: '世'}, {}, {Rune: 'a'}}, s.Line(0)[:3])
+}
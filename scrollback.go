@@ -0,0 +1,143 @@
+package terminal
+
+import (
+	"context"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const dragScrollInterval = 50 * time.Millisecond
+
+// ScrollToBottom returns the view to the current screen if it has been scrolled back through the scrollback.
+func (t *Terminal) ScrollToBottom() {
+	t.scrollView(-t.scrollOffset)
+}
+
+// SetScrollOnOutput sets whether new output returns the view to the screen when it is scrolled back.
+// The default is false, so that the scrollback can be read while output continues.
+func (t *Terminal) SetScrollOnOutput(scroll bool) {
+	t.scrollOnOutput = scroll
+}
+
+// SetScrollOnKeystroke sets whether typing returns the view to the screen when it is scrolled back.
+// The default is true.
+func (t *Terminal) SetScrollOnKeystroke(scroll bool) {
+	t.scrollOnKeystroke = scroll
+}
+
+// Scrolled is called when the user scrolls over the terminal, scrolling up shows lines from the scrollback.
+// If the program is tracking the mouse the wheel is reported to it instead.
+func (t *Terminal) Scrolled(ev *fyne.ScrollEvent) {
+	cellHeight := t.guessCellSize().Height
+	t.scrollRemainder += ev.Scrolled.DY
+	lines := int(t.scrollRemainder / cellHeight)
+	t.scrollRemainder -= float32(lines) * cellHeight
+
+	if down, _ := t.mouseHandlers(); down != nil {
+		t.reportWheel(lines, ev.Position) // the program is tracking the mouse
+		return
+	}
+	t.scrollView(lines)
+}
+
+// scrollView moves the view by the given number of lines, positive values move back into the scrollback.
+// Any selection moves with the content. It returns the number of lines that the view moved.
+func (t *Terminal) scrollView(lines int) int {
+	offset := t.scrollOffset + lines
+	if offset > len(t.screen.scrollback) {
+		offset = len(t.screen.scrollback)
+	}
+	if offset < 0 || t.screen.altScreen {
+		offset = 0
+	}
+	moved := offset - t.scrollOffset
+	if moved == 0 {
+		return 0
+	}
+
+	t.scrollOffset = offset
+	t.syncGrid()
+	if t.selStart != nil {
+		t.selStart.Row += moved
+	}
+	if t.selEnd != nil {
+		t.selEnd.Row += moved
+	}
+	if t.hasSelectedText() {
+		t.highlightSelectedText() // the rows in view were replaced
+	}
+
+	t.Refresh()
+	return moved
+}
+
+// viewRows returns the rows visible at the current scroll offset, from the scrollback followed by the screen.
+func (t *Terminal) viewRows() []gridRow {
+	if t.scrollOffset == 0 {
+		return t.screen.content.Rows
+	}
+
+	start := len(t.screen.scrollback) - t.scrollOffset
+	view := make([]gridRow, 0, t.screen.config.Rows)
+	for i := start; i < start+int(t.screen.config.Rows); i++ {
+		if i < len(t.screen.scrollback) {
+			view = append(view, t.screen.scrollback[i])
+		} else if i-len(t.screen.scrollback) < len(t.screen.content.Rows) {
+			view = append(view, t.screen.content.Rows[i-len(t.screen.scrollback)])
+		}
+	}
+	return view
+}
+
+// startDragScroll scrolls the view repeatedly while a selection is dragged past the top or bottom edge,
+// extending the selection to the edge row as new lines come into view.
+func (t *Terminal) startDragScroll(direction int) {
+	t.dragScroll = direction
+	if t.dragScrollCancel != nil {
+		return
+	}
+
+	var scrollContext context.Context
+	scrollContext, t.dragScrollCancel = context.WithCancel(context.Background())
+	go func() {
+		for {
+			select {
+			case <-scrollContext.Done():
+				return
+			case <-time.After(dragScrollInterval):
+			}
+
+			t.screen.stateLock.Lock()
+			if scrollContext.Err() == nil { // not stopped while we waited for the lock
+				t.dragScrollStep()
+			}
+			t.screen.stateLock.Unlock()
+		}
+	}()
+}
+
+func (t *Terminal) stopDragScroll() {
+	if t.dragScrollCancel == nil {
+		return
+	}
+
+	t.dragScrollCancel()
+	t.dragScrollCancel = nil
+	t.dragScroll = 0
+}
+
+func (t *Terminal) dragScrollStep() {
+	if t.selEnd == nil {
+		return
+	}
+
+	t.scrollView(t.dragScroll)
+	t.clearHighlight()
+	row := 1
+	if t.dragScroll < 0 {
+		row = int(t.screen.config.Rows)
+	}
+	t.selEnd = &position{Col: t.selEnd.Col, Row: row}
+	t.highlightSelectedText()
+}
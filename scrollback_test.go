@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrollView(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("1\r\n2\r\n3\r\n4"))
+	assert.Equal(t, "3\n4", term.Text())
+
+	assert.Equal(t, 1, term.scrollView(1))
+	assert.Equal(t, "2\n3", term.Text())
+	assert.Equal(t, 1, term.scrollView(5)) // limited by the scrollback
+	assert.Equal(t, "1\n2", term.Text())
+	assert.Equal(t, "1\n2\n3\n4", term.FullText())
+
+	term.scrollView(-1)
+	term.handleOutput([]byte("\r\n5"))
+	assert.Equal(t, "2\n3", term.Text()) // the view does not move with new output
+	assert.Equal(t, "1\n2\n3\n4\n5", term.FullText())
+
+	term.ScrollToBottom()
+	assert.Equal(t, "4\n5", term.Text())
+}
+
+func TestScrollView_ScrollOnOutputAndKeystroke(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.in = NopCloser(&bytes.Buffer{})
+	term.handleOutput([]byte("1\r\n2\r\n3\r\n4"))
+
+	term.scrollView(2)
+	term.TypedRune('x')
+	assert.Equal(t, 0, term.scrollOffset)
+
+	term.SetScrollOnKeystroke(false)
+	term.scrollView(2)
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyUp})
+	assert.Equal(t, 2, term.scrollOffset)
+
+	term.SetScrollOnOutput(true)
+	term.handleOutput([]byte("5"))
+	assert.Equal(t, 0, term.scrollOffset)
+	assert.Equal(t, "3\n45", term.Text())
+}
+
+func TestScrollView_DragScrollDuringOutput(t *testing.T) {
+	term := New()
+	term.SetGridSize(2, 5)
+	r, w := io.Pipe()
+	go func() {
+		for i := 0; i < 100; i++ {
+			_, _ = w.Write([]byte("line\r\n"))
+			time.Sleep(time.Millisecond)
+		}
+		_ = w.Close()
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- term.RunWithConnection(NopCloser(&bytes.Buffer{}), r)
+	}()
+	term.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(1, -5)}})
+	time.Sleep(4 * dragScrollInterval)
+	term.DragEnd()
+	assert.Nil(t, <-done)
+}
+
+func TestScrollView_DragSelection(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("1\r\n2\r\n3\r\n4\r\n5"))
+
+	term.selStart = &position{Col: 1, Row: 2}
+	term.selEnd = &position{Col: 1, Row: 2}
+	term.dragScroll = 1
+	term.dragScrollStep()
+	term.dragScrollStep()
+	assert.Equal(t, "2\n3", term.Text())
+	assert.Equal(t, "2\n3\n4\n5", term.SelectedText())
+
+	term.clearSelectedText()
+	term.ScrollToBottom()
+	assert.Equal(t, "1\n2\n3\n4\n5", term.FullText())
+}
@@ -1,7 +1,12 @@
 package terminal
 
 import (
+	"strings"
+	"unicode"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
 	widget2 "github.com/fyne-io/terminal/internal/widget"
 )
 
@@ -40,15 +45,42 @@ func (t *Terminal) getSelectedRange() (int, int, int, int) {
 	return startRow - 1, startCol - 1, endRow - 1, endCol - 1
 }
 
+// selectionBuffer returns a grid of the rows from startRow to endRow of the view, which may be in the
+// scrollback above or below the rows shown, and the index in that grid of the first row shown in the terminal.
+func (t *Terminal) selectionBuffer(startRow, endRow int) (*widget2.TermGrid, int) {
+	top := len(t.screen.scrollback) - t.scrollOffset
+	first := startRow + top
+	if first < 0 {
+		first = 0
+	}
+	var rows []widget.TextGridRow
+	for i := first; i <= endRow+top; i++ {
+		row, ok := t.screen.bufferRow(i)
+		if !ok {
+			break
+		}
+		rows = append(rows, t.textGridRow(row))
+	}
+	return &widget2.TermGrid{TextGrid: widget.TextGrid{Rows: rows}}, top - first
+}
+
+// highlightSelectedText highlights the part of the selection that is in view.
 func (t *Terminal) highlightSelectedText() {
 	sr, sc, er, ec := t.getSelectedRange()
-	widget2.HighlightRange(t.content, t.blockMode, sr, sc, er, ec, t.highlightBitMask)
+	widget2.HighlightRange(t.grid, t.blockMode, sr, sc, er, ec, t.highlightBitMask)
+	t.grid.MarkRowsDirty(sr, er)
 	t.Refresh()
 }
 
-func (t *Terminal) clearSelectedText() {
+// clearHighlight removes the highlight from the selected range without ending the selection.
+func (t *Terminal) clearHighlight() {
 	sr, sc, er, ec := t.getSelectedRange()
-	widget2.ClearHighlightRange(t.content, t.blockMode, sr, sc, er, ec)
+	widget2.ClearHighlightRange(t.grid, t.blockMode, sr, sc, er, ec)
+	t.grid.MarkRowsDirty(sr, er)
+}
+
+func (t *Terminal) clearSelectedText() {
+	t.clearHighlight()
 	t.Refresh()
 	t.blockMode = false
 	t.selecting = false
@@ -57,7 +89,14 @@ func (t *Terminal) clearSelectedText() {
 // SelectedText gets the text that is currently selected.
 func (t *Terminal) SelectedText() string {
 	sr, sc, er, ec := t.getSelectedRange()
-	return widget2.GetTextRange(t.content, t.blockMode, sr, sc, er, ec)
+	buf, top := t.selectionBuffer(sr, er)
+	return widget2.GetTextRange(buf, t.blockMode, sr+top, sc, er+top, ec)
+}
+
+// SetCopyOnSelect sets whether clicking the secondary mouse button over a selection copies it to the clipboard.
+// The default is true. When turned off the selection is only copied by the copy shortcut.
+func (t *Terminal) SetCopyOnSelect(enabled bool) {
+	t.copyOnSelect = enabled
 }
 
 func (t *Terminal) copySelectedText(clipboard fyne.Clipboard) {
@@ -68,19 +107,63 @@ func (t *Terminal) copySelectedText(clipboard fyne.Clipboard) {
 	t.clearSelectedText()
 }
 
+// clipboard returns the clipboard of the window showing this terminal, or nil if it is not in a window.
+func (t *Terminal) clipboard() fyne.Clipboard {
+	a := fyne.CurrentApp()
+	if a == nil {
+		return nil
+	}
+	c := a.Driver().CanvasForObject(t)
+	if c == nil {
+		return nil
+	}
+
+	for _, w := range a.Driver().AllWindows() {
+		if w.Canvas() == c {
+			return w.Clipboard()
+		}
+	}
+	return nil
+}
+
 func (t *Terminal) pasteText(clipboard fyne.Clipboard) {
-	content := clipboard.Content()
+	t.PasteString(clipboard.Content())
+}
 
-	if t.bracketedPasteMode {
-		_, _ = t.in.Write(append(
+// Paste sends the contents of the clipboard to the terminal, in the same way as the paste shortcut.
+func (t *Terminal) Paste() {
+	if c := t.clipboard(); c != nil {
+		t.pasteText(c)
+	}
+}
+
+// PasteString sends the text to the terminal as if it had been pasted. Line endings are sent as a carriage
+// return, like the Return key, and other control characters are removed so that pasted text cannot contain
+// escape sequences. If the program has asked for bracketed paste the text is marked as pasted.
+func (t *Terminal) PasteString(text string) {
+	content := []byte(sanitizePaste(text))
+
+	if t.screen.bracketedPasteMode {
+		_, _ = t.Write(append(
 			append(
 				[]byte{asciiEscape, '[', '2', '0', '0', '~'},
-				[]byte(content)...),
+				content...),
 			[]byte{asciiEscape, '[', '2', '0', '1', '~'}...),
 		)
 		return
 	}
-	_, _ = t.in.Write([]byte(content))
+	_, _ = t.Write(content)
+}
+
+// sanitizePaste converts line endings to carriage returns and removes any control characters other than tab.
+func sanitizePaste(text string) string {
+	text = strings.NewReplacer("\r\n", "\r", "\n", "\r").Replace(text)
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' && r != '\r' {
+			return -1
+		}
+		return r
+	}, text)
 }
 
 func (t *Terminal) hasSelectedText() bool {
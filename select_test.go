@@ -1,9 +1,14 @@
 package terminal
 
 import (
+	"bytes"
 	"testing"
 
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
 	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+
 	widget2 "github.com/fyne-io/terminal/internal/widget"
 )
 
@@ -109,3 +114,46 @@ func TestGetTextRange(t *testing.T) {
 		})
 	}
 }
+
+func TestTerminal_SetCopyOnSelect(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+	term := New()
+	term.screen.config.Columns = 10
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte("hello"))
+	w := test.NewWindow(term)
+	defer w.Close()
+	w.Clipboard().SetContent("old")
+	click := &desktop.MouseEvent{Button: desktop.MouseButtonSecondary}
+
+	term.SetCopyOnSelect(false)
+	term.selStart, term.selEnd = &position{Row: 1, Col: 1}, &position{Row: 1, Col: 5}
+	term.MouseUp(click)
+	assert.Equal(t, "old", w.Clipboard().Content())
+	assert.True(t, term.hasSelectedText())
+
+	term.SetCopyOnSelect(true)
+	term.MouseUp(click)
+	assert.Equal(t, "hello", w.Clipboard().Content())
+	assert.False(t, term.hasSelectedText())
+}
+
+func TestTerminal_Paste(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	w := test.NewWindow(term)
+	defer w.Close()
+
+	w.Clipboard().SetContent("ls\r\ncd\n")
+	term.Paste()
+	assert.Equal(t, "ls\rcd\r", inBuffer.String())
+
+	inBuffer.Reset()
+	term.screen.bracketedPasteMode = true
+	term.PasteString("a\tb\x1b[201~\x07c\u009bd")
+	assert.Equal(t, "\x1b[200~a\tb[201~cd\x1b[201~", inBuffer.String())
+}
@@ -0,0 +1,14 @@
+package terminal
+
+// RunWithSerial opens the named serial port (such as "/dev/ttyUSB0") at the given baud rate,
+// with 8 data bits, no parity and one stop bit in raw mode, and runs the terminal connected to it.
+// An error is returned if the port cannot be opened or configured.
+// The port is closed when the connection ends.
+func (t *Terminal) RunWithSerial(portName string, baud int) error {
+	port, err := openSerial(portName, baud)
+	if err != nil {
+		return err
+	}
+
+	return t.RunWithConnection(port, port)
+}
@@ -0,0 +1,13 @@
+package terminal
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
+
+func setSerialSpeed(tio *unix.Termios, baud int) error {
+	tio.Ispeed, tio.Ospeed = uint64(baud), uint64(baud)
+	return nil
+}
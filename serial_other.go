@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package terminal
+
+import (
+	"errors"
+	"io"
+)
+
+func openSerial(string, int) (io.ReadWriteCloser, error) {
+	return nil, errors.New("serial ports are not supported on this platform")
+}
@@ -0,0 +1,13 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWithSerial_OpenError(t *testing.T) {
+	term := New()
+	err := term.RunWithSerial("/dev/no-such-serial-port", 9600)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,42 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func openSerial(name string, baud int) (*os.File, error) {
+	// open without waiting for carrier detect, Fd() below puts the file back in blocking mode
+	f, err := os.OpenFile(name, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fd := int(f.Fd())
+	tio, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		_ = f.Close()
+		return nil, &os.PathError{Op: "configure", Path: name, Err: err}
+	}
+
+	tio.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL |
+		unix.IXON
+	tio.Oflag &^= unix.OPOST
+	tio.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	tio.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB
+	tio.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL
+	tio.Cc[unix.VMIN] = 1
+	tio.Cc[unix.VTIME] = 0
+	if err = setSerialSpeed(tio, baud); err == nil {
+		err = unix.IoctlSetTermios(fd, ioctlSetTermios, tio)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, &os.PathError{Op: "configure", Path: name, Err: err}
+	}
+	return f, nil
+}
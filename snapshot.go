@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"image"
+	"image/draw"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/software"
+	"fyne.io/fyne/v2/theme"
+
+	widget2 "github.com/fyne-io/terminal/internal/widget"
+)
+
+// Snapshot paints the visible terminal content, including colours and the cursor, into an image.
+// The terminal does not need to be shown in a window for this to work.
+func (t *Terminal) Snapshot() image.Image {
+	return t.SnapshotRegion(0, t.snapshotRowCount()-1)
+}
+
+// SnapshotRegion paints the rows from startRow to endRow (inclusive) into an image.
+// Row numbers are clamped to the content of the terminal.
+func (t *Terminal) SnapshotRegion(startRow, endRow int) image.Image {
+	if startRow < 0 {
+		startRow = 0
+	}
+	if last := t.snapshotRowCount() - 1; endRow > last {
+		endRow = last
+	}
+	if endRow < startRow {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	grid := widget2.NewTermGrid()
+	grid.TextSize = t.grid.TextSize
+	grid.LineSpacing = t.grid.LineSpacing
+	grid.DefaultBackground = t.grid.DefaultBackground
+	grid.ForcedForeground, grid.ForcedBackground = t.grid.ForcedForeground, t.grid.ForcedBackground
+	grid.FallbackFonts = t.grid.FallbackFonts
+	grid.Ligatures = t.grid.Ligatures
+	if startRow < len(t.grid.Rows) {
+		end := endRow + 1
+		if end > len(t.grid.Rows) {
+			end = len(t.grid.Rows)
+		}
+		grid.Rows = t.grid.Rows[startRow:end]
+	}
+
+	cell := t.guessCellSize()
+	size := fyne.NewSize(cell.Width*float32(t.screen.config.Columns), cell.Height*float32(endRow-startRow+1))
+	objects := []fyne.CanvasObject{grid}
+	if !t.screen.cursorHidden && t.screen.cursorRow >= startRow && t.screen.cursorRow <= endRow {
+		cursor := canvas.NewRectangle(theme.PrimaryColor())
+		pos := fyne.NewPos(cell.Width*float32(t.screen.cursorCol), cell.Height*float32(t.screen.cursorRow-startRow))
+		switch t.screen.cursorShape {
+		case CursorShapeBlock:
+			cursor.Resize(cell)
+			objects = []fyne.CanvasObject{cursor, grid} // draw the block behind the text
+		case CursorShapeUnderline:
+			cursor.Resize(fyne.NewSize(cell.Width, cursorWidth))
+			pos.Y += cell.Height - cursorWidth
+			objects = append(objects, cursor)
+		default:
+			cursor.Resize(fyne.NewSize(cursorWidth, cell.Height))
+			objects = append(objects, cursor)
+		}
+		cursor.Move(pos)
+	}
+
+	c := software.NewCanvas()
+	c.SetPadded(false)
+	c.SetContent(container.NewWithoutLayout(objects...))
+	c.Resize(size)
+	grid.Resize(size)
+	grid.Refresh()
+
+	img := c.Capture()
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	return out
+}
+
+func (t *Terminal) snapshotRowCount() int {
+	if len(t.grid.Rows) > int(t.screen.config.Rows) {
+		return len(t.grid.Rows)
+	}
+	return int(t.screen.config.Rows)
+}
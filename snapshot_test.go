@@ -0,0 +1,37 @@
+package terminal
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminal_Snapshot(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 4
+	term.screen.config.Rows = 2
+	term.handleOutput([]byte(esc("[41m") + "Hi"))
+
+	cell := term.guessCellSize()
+	img := term.Snapshot()
+	assert.Equal(t, image.Rect(0, 0, int(cell.Width*4), int(cell.Height*2)), img.Bounds())
+	assert.Equal(t, color.RGBA{170, 0, 0, 255}, color.RGBAModel.Convert(img.At(0, 0)))
+}
+
+func TestTerminal_SnapshotRegion(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 4
+	term.screen.config.Rows = 3
+	term.screen.scrollBottom = 2
+	term.handleOutput([]byte("a\r\n" + esc("[42m") + "b"))
+
+	cell := term.guessCellSize()
+	img := term.SnapshotRegion(1, 5)
+	assert.Equal(t, image.Rect(0, 0, int(cell.Width*4), int(cell.Height*2)), img.Bounds())
+	assert.Equal(t, color.RGBA{0, 170, 0, 255}, color.RGBAModel.Convert(img.At(0, 0)))
+
+	img = term.SnapshotRegion(2, 1)
+	assert.True(t, img.Bounds().Empty())
+}
@@ -0,0 +1,300 @@
+package terminal
+
+import (
+	"io"
+	"sync"
+)
+
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWill = 251
+	telnetWont = 252
+	telnetDo   = 253
+	telnetDont = 254
+	telnetIAC  = 255
+
+	telnetOptBinary = 0
+	telnetOptEcho   = 1
+	telnetOptSGA    = 3
+	telnetOptTType  = 24
+	telnetOptNAWS   = 31
+
+	telnetTTypeIs   = 0
+	telnetTTypeSend = 1
+
+	telnetTerminalType = "xterm-256color"
+
+	maxTelnetSubLength = 1024 // longer subnegotiations are dropped, none that we handle come close
+)
+
+// the options that we will perform, and those we ask the other end to perform
+var (
+	telnetLocalOptions  = map[byte]bool{telnetOptBinary: true, telnetOptSGA: true, telnetOptTType: true, telnetOptNAWS: true}
+	telnetRemoteOptions = map[byte]bool{telnetOptBinary: true, telnetOptEcho: true, telnetOptSGA: true}
+)
+
+type telnetState int
+
+const (
+	telnetData telnetState = iota
+	telnetCommand
+	telnetOption
+	telnetSub
+	telnetSubIAC
+	telnetCR
+)
+
+// TelnetConn wraps a connection to a telnet server, handling option negotiation so that the
+// terminal only sees the session data. Pass it as both arguments of RunWithConnection, and pass
+// its WindowChanged method to SetWinchHandler so that the server is told the window size (NAWS):
+//
+//	conn, _ := net.Dial("tcp", "bbs.example.com:23")
+//	tc := terminal.NewTelnetConn(conn)
+//	t.SetWinchHandler(tc.WindowChanged)
+//	err := t.RunWithConnection(tc, tc)
+type TelnetConn struct {
+	conn io.ReadWriteCloser
+
+	writeLock sync.Mutex
+	state     telnetState
+	command   byte
+	sub       []byte
+	subLong   bool // the subnegotiation was longer than maxTelnetSubLength
+
+	optionLock     sync.Mutex
+	local, remote  map[byte]bool // options that are enabled
+	offered, asked map[byte]bool // options we sent WILL or DO for, but have no answer yet
+	rows, cols     uint
+}
+
+// NewTelnetConn wraps the given connection and starts negotiating binary transmission,
+// suppress go ahead, server echo and window size options.
+func NewTelnetConn(conn io.ReadWriteCloser) *TelnetConn {
+	c := &TelnetConn{conn: conn,
+		local: make(map[byte]bool), remote: make(map[byte]bool),
+		offered: make(map[byte]bool), asked: make(map[byte]bool)}
+
+	for _, opt := range []byte{telnetOptBinary, telnetOptSGA, telnetOptNAWS} {
+		c.offered[opt] = true
+		c.sendCommand(telnetWill, opt)
+	}
+	for _, opt := range []byte{telnetOptBinary, telnetOptSGA, telnetOptEcho} {
+		c.asked[opt] = true
+		c.sendCommand(telnetDo, opt)
+	}
+	return c
+}
+
+// Close closes the underlying connection.
+func (c *TelnetConn) Close() error {
+	return c.conn.Close()
+}
+
+// Read returns the session data from the server, with any telnet commands removed.
+func (c *TelnetConn) Read(p []byte) (int, error) {
+	for {
+		n, err := c.conn.Read(p)
+		out := 0
+		for _, b := range p[:n] {
+			if c.readByte(b) {
+				p[out] = b
+				out++
+			}
+		}
+		if out > 0 || err != nil {
+			return out, err
+		}
+	}
+}
+
+// Write sends the data to the server, escaping any bytes that would be read as telnet commands.
+func (c *TelnetConn) Write(p []byte) (int, error) {
+	c.optionLock.Lock()
+	binary := c.local[telnetOptBinary]
+	c.optionLock.Unlock()
+
+	data := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch {
+		case b == telnetIAC:
+			data = append(data, telnetIAC, telnetIAC)
+		case b == '\r' && !binary:
+			data = append(data, '\r', 0) // a bare CR is sent as CR NUL
+		default:
+			data = append(data, b)
+		}
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if _, err := c.conn.Write(data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WindowChanged tells the server the new size of the terminal, if it asked to be told (NAWS).
+// It has the signature required by Terminal.SetWinchHandler.
+func (c *TelnetConn) WindowChanged(rows, cols uint, _, _ uint16) {
+	c.optionLock.Lock()
+	defer c.optionLock.Unlock()
+	c.rows, c.cols = rows, cols
+	if c.local[telnetOptNAWS] {
+		c.sendSize()
+	}
+}
+
+// readByte processes a byte from the server, returning true if it is session data.
+func (c *TelnetConn) readByte(b byte) bool {
+	switch c.state {
+	case telnetCR:
+		c.state = telnetData
+		if b == 0 {
+			return false // CR NUL is a bare CR
+		}
+		fallthrough
+	case telnetData:
+		if b == telnetIAC {
+			c.state = telnetCommand
+			return false
+		}
+		c.optionLock.Lock()
+		binary := c.remote[telnetOptBinary]
+		c.optionLock.Unlock()
+		if b == '\r' && !binary {
+			c.state = telnetCR
+		}
+		return true
+	case telnetCommand:
+		switch b {
+		case telnetIAC:
+			c.state = telnetData
+			return true // an escaped 255
+		case telnetWill, telnetWont, telnetDo, telnetDont:
+			c.command = b
+			c.state = telnetOption
+		case telnetSB:
+			c.sub = c.sub[:0]
+			c.subLong = false
+			c.state = telnetSub
+		default:
+			c.state = telnetData // other commands, such as NOP or GA, are ignored
+		}
+	case telnetOption:
+		c.state = telnetData
+		c.negotiate(c.command, b)
+	case telnetSub:
+		if b == telnetIAC {
+			c.state = telnetSubIAC
+		} else {
+			c.appendSub(b)
+		}
+	case telnetSubIAC:
+		switch b {
+		case telnetSE:
+			c.state = telnetData
+			if !c.subLong {
+				c.subnegotiate(c.sub)
+			}
+		case telnetIAC:
+			c.appendSub(telnetIAC)
+			c.state = telnetSub
+		default:
+			c.state = telnetSub
+		}
+	}
+	return false
+}
+
+// appendSub adds a byte to the subnegotiation, marking it to be dropped if it grows too long.
+func (c *TelnetConn) appendSub(b byte) {
+	if len(c.sub) >= maxTelnetSubLength {
+		c.subLong = true
+		return
+	}
+	c.sub = append(c.sub, b)
+}
+
+// negotiate answers a WILL, WONT, DO or DONT from the server.
+// Replies are only sent when an option changes, so that the two ends do not loop.
+func (c *TelnetConn) negotiate(command, opt byte) {
+	c.optionLock.Lock()
+	defer c.optionLock.Unlock()
+
+	switch command {
+	case telnetDo:
+		if !telnetLocalOptions[opt] {
+			c.sendCommand(telnetWont, opt)
+			return
+		}
+		if !c.local[opt] {
+			c.local[opt] = true
+			if !c.offered[opt] {
+				c.sendCommand(telnetWill, opt)
+			}
+		}
+		delete(c.offered, opt)
+		if opt == telnetOptNAWS {
+			c.sendSize()
+		}
+	case telnetDont:
+		if c.local[opt] {
+			c.local[opt] = false
+			c.sendCommand(telnetWont, opt)
+		}
+		delete(c.offered, opt)
+	case telnetWill:
+		if !telnetRemoteOptions[opt] {
+			c.sendCommand(telnetDont, opt)
+			return
+		}
+		if !c.remote[opt] {
+			c.remote[opt] = true
+			if !c.asked[opt] {
+				c.sendCommand(telnetDo, opt)
+			}
+		}
+		delete(c.asked, opt)
+	case telnetWont:
+		if c.remote[opt] {
+			c.remote[opt] = false
+			c.sendCommand(telnetDont, opt)
+		}
+		delete(c.asked, opt)
+	}
+}
+
+func (c *TelnetConn) subnegotiate(data []byte) {
+	if len(data) == 2 && data[0] == telnetOptTType && data[1] == telnetTTypeSend {
+		reply := append([]byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeIs}, telnetTerminalType...)
+		c.send(append(reply, telnetIAC, telnetSE))
+	}
+}
+
+// sendSize sends the window size to the server, it must be called with optionLock held.
+func (c *TelnetConn) sendSize() {
+	rows, cols := c.rows, c.cols
+	if rows == 0 || cols == 0 {
+		return // not yet known, it will be sent by WindowChanged
+	}
+
+	msg := []byte{telnetIAC, telnetSB, telnetOptNAWS}
+	for _, b := range []byte{byte(cols >> 8), byte(cols), byte(rows >> 8), byte(rows)} {
+		msg = append(msg, b)
+		if b == telnetIAC {
+			msg = append(msg, telnetIAC)
+		}
+	}
+	c.send(append(msg, telnetIAC, telnetSE))
+}
+
+func (c *TelnetConn) sendCommand(command, opt byte) {
+	c.send([]byte{telnetIAC, command, opt})
+}
+
+func (c *TelnetConn) send(data []byte) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, _ = c.conn.Write(data)
+}
@@ -0,0 +1,82 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTelnetServer struct {
+	in  *bytes.Reader
+	out bytes.Buffer
+}
+
+func (s *testTelnetServer) Read(p []byte) (int, error) {
+	return s.in.Read(p)
+}
+
+func (s *testTelnetServer) Write(p []byte) (int, error) {
+	return s.out.Write(p)
+}
+
+func (s *testTelnetServer) Close() error {
+	return nil
+}
+
+func TestTelnetConn_Negotiation(t *testing.T) {
+	server := &testTelnetServer{in: bytes.NewReader([]byte{
+		telnetIAC, telnetDo, telnetOptNAWS, 'h', 'i',
+		telnetIAC, telnetWill, telnetOptEcho, telnetIAC, telnetIAC,
+		telnetIAC, telnetDo, 42,
+		telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE, '!'})}
+	conn := NewTelnetConn(server)
+	assert.Equal(t, []byte{telnetIAC, telnetWill, telnetOptBinary, telnetIAC, telnetWill, telnetOptSGA,
+		telnetIAC, telnetWill, telnetOptNAWS, telnetIAC, telnetDo, telnetOptBinary, telnetIAC, telnetDo, telnetOptSGA,
+		telnetIAC, telnetDo, telnetOptEcho}, server.out.Bytes())
+	server.out.Reset()
+
+	data, err := io.ReadAll(conn)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{'h', 'i', telnetIAC, '!'}, data)
+	reply := append([]byte{telnetIAC, telnetWont, 42,
+		telnetIAC, telnetSB, telnetOptTType, telnetTTypeIs}, telnetTerminalType...)
+	assert.Equal(t, append(reply, telnetIAC, telnetSE), server.out.Bytes()) // no replies to acknowledgements
+	server.out.Reset()
+
+	conn.WindowChanged(24, 255, 0, 0)
+	assert.Equal(t, []byte{telnetIAC, telnetSB, telnetOptNAWS, 0, telnetIAC, telnetIAC, 0, 24, telnetIAC, telnetSE},
+		server.out.Bytes())
+}
+
+func TestTelnetConn_Write(t *testing.T) {
+	server := &testTelnetServer{in: bytes.NewReader(nil)}
+	conn := NewTelnetConn(server)
+	server.out.Reset()
+
+	n, err := conn.Write([]byte{'l', 's', telnetIAC, '\r'})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, []byte{'l', 's', telnetIAC, telnetIAC, '\r', 0}, server.out.Bytes())
+
+	conn.negotiate(telnetDo, telnetOptBinary)
+	server.out.Reset()
+	_, _ = conn.Write([]byte{'\r'})
+	assert.Equal(t, []byte{'\r'}, server.out.Bytes())
+}
+
+func TestTelnetConn_LongSubnegotiation(t *testing.T) {
+	in := []byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend}
+	in = append(in, bytes.Repeat([]byte{'x'}, maxTelnetSubLength)...)
+	in = append(in, telnetIAC, telnetSE, 'o', 'k')
+	server := &testTelnetServer{in: bytes.NewReader(in)}
+	conn := NewTelnetConn(server)
+	server.out.Reset()
+
+	data, err := io.ReadAll(conn)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{'o', 'k'}, data)
+	assert.Empty(t, server.out.Bytes()) // the oversized request is dropped without a reply
+	assert.LessOrEqual(t, len(conn.sub), maxTelnetSubLength)
+}
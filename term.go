@@ -1,9 +1,12 @@
 package terminal
 
 import (
+	"context"
+	"errors"
 	"image/color"
 	"io"
 	"math"
+	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
@@ -14,14 +17,38 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/driver/mobile"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	widget2 "github.com/fyne-io/terminal/internal/widget"
 )
 
 const (
-	bufLen = 32768 // 32KB buffer for output, to align with modern L1 cache
+	bufLen             = 32768 // 32KB buffer for output, to align with modern L1 cache
+	defaultRefreshRate = 60    // maximum number of times per second that new output is drawn
+	scrollbackLines    = 1000  // maximum number of lines kept after they scroll off the top of the screen
 )
 
+// ErrTerminalClosed is returned, wrapping the underlying error, when writing to a terminal whose
+// connection has not been established or has closed. Use errors.Is to check for it.
+var ErrTerminalClosed = errors.New("terminal is closed")
+
+// closedError reports that the connection has gone, while keeping the error that told us so.
+type closedError struct {
+	err error
+}
+
+func (e closedError) Error() string {
+	return ErrTerminalClosed.Error() + ": " + e.err.Error()
+}
+
+func (e closedError) Is(target error) bool {
+	return target == ErrTerminalClosed
+}
+
+func (e closedError) Unwrap() error {
+	return e.err
+}
+
 // Config is the state of a terminal, updated upon certain actions or commands.
 // Use Terminal.OnConfigure hook to register for changes.
 type Config struct {
@@ -29,61 +56,108 @@ type Config struct {
 	Rows, Columns uint
 }
 
-type charSet int
-
-const (
-	charSetANSII charSet = iota
-	charSetDECSpecialGraphics
-	charSetAlternate
-)
-
 // Terminal is a terminal widget that loads a shell and handles input/output.
+// The emulation is done by a Screen, whose content it draws.
 type Terminal struct {
 	widget.BaseWidget
 	fyne.ShortcutHandler
-	content      *widget2.TermGrid
-	config       Config
-	listenerLock sync.Mutex
-	listeners    []chan Config
-	startDir     string
+	screen     *Screen           // the emulation, guarded by its state lock
+	grid       *widget2.TermGrid // the rows in view, copied from the screen and scrollback
+	viewOffset int               // the scroll offset that grid was last filled at
+	startDir   string
 
 	pty io.Closer
 	in  io.WriteCloser
 	out io.Reader
 
-	bell, bold, debug, focused bool
-	currentFG, currentBG       color.Color
-	cursorRow, cursorCol       int
-	savedRow, savedCol         int
-	scrollTop, scrollBottom    int
-
-	cursor                   *canvas.Rectangle
-	cursorHidden, bufferMode bool // buffer mode is an xterm extension that impacts control keys
-	cursorMoved              func()
-
-	onMouseDown, onMouseUp func(int, fyne.KeyModifier, fyne.Position)
-	g0Charset              charSet
-	g1Charset              charSet
-	useG1CharSet           bool
+	bell, focused bool
+	bellHandler   func()
+	visualBell    bool
+
+	cursor            *canvas.Rectangle
+	backgroundImage   *canvas.Image
+	background        *canvas.Rectangle // the theme background, only drawn once an opacity is set
+	backgroundOpacity float32
+	scrollOffset      int     // how many lines back into the scrollback the view is scrolled
+	scrollRemainder   float32 // scroll distance not yet used to move a whole line
+	scrollOnOutput    bool
+	scrollOnKeystroke bool
+	dragScroll        int // the direction the view scrolls while dragging past an edge
+	dragScrollCancel  context.CancelFunc
+	clearShortcut     fyne.Shortcut
+
+	cursorHollowUnfocused bool
+	cursorBlinkEnabled    bool
+	cursorBlinkRate       time.Duration
+	cursorBlinkOff        bool
+	cursorBlinkCancel     context.CancelFunc
 
 	selStart, selEnd *position
 	blockMode        bool
 	highlightBitMask uint8
 	selecting        bool
+	copyOnSelect     bool // the secondary mouse button copies the selection
 	mouseCursor      desktop.Cursor
 
+	copyMode         bool
+	copyAnchored     bool // a selection has been started from the copy mode cursor
+	copyCursor       position
+	copyModeShortcut fyne.Shortcut
+
+	urlDetection   bool
+	urlOpenHandler func(*url.URL)
+	hoveredLink    *link
+	linkUnderline  *fyne.Container
+
+	imageObjects map[*inlineImage]*canvas.Image
+	imageLayer   *fyne.Container
+	imagesBelow  *fyne.Container // images drawn underneath the text
+
 	keyboardState struct {
-		shiftPressed bool
-		ctrlPressed  bool
-		altPressed   bool
+		shiftPressed  bool
+		ctrlPressed   bool
+		altPressed    bool
+		insertPressed bool
 	}
-	newLineMode        bool // new line mode or line feed mode
-	bracketedPasteMode bool
-	state              *parseState
-	blinking           bool
-	printData          []byte
-	printer            Printer
-	cmd                *exec.Cmd
+	altSendsEscape bool
+	localEcho      bool
+	cmd            *exec.Cmd
+	outputFilter   func([]byte) []byte
+	resizeCallback func(rows, cols uint)
+	winchHandler   func(rows, cols uint, pxW, pxH uint16)
+	ptyFactory     PTYFactory
+	ptyCancel      context.CancelFunc
+
+	minRows, minCols uint
+
+	resizeLock     sync.Mutex
+	resizeDebounce time.Duration
+	resizeTimer    *time.Timer
+
+	recordLock sync.Mutex
+	recorder   *recorder
+
+	traceLock sync.Mutex
+	traceOut  io.Writer
+	traceRing *traceRing
+
+	activityLock     sync.Mutex
+	activityCallback func()
+	lastActivity     time.Time
+	silenceTimer     *time.Timer
+	silenceDuration  time.Duration
+
+	// closeLock guards the connection fields, in, out, pty, ptyCancel and cmd, once a session has been started
+	closeLock sync.Mutex
+	closing   bool
+	runDone   chan struct{}    // closed when run returns
+	exited    *os.ProcessState // the state of the shell once it has exited
+	echoed    chan []byte      // local echo, passed to run so that it is handled in order with the output
+
+	refreshLock     sync.Mutex
+	refreshInterval time.Duration
+	refreshPending  bool
+	lastRefresh     time.Time
 }
 
 // Printer is used for spooling print data when its received.
@@ -109,17 +183,12 @@ func (t *Terminal) AcceptsTab() bool {
 	return true
 }
 
-// AddListener registers a new outgoing channel that will have our Config sent each time it changes.
-func (t *Terminal) AddListener(listener chan Config) {
-	t.listenerLock.Lock()
-	defer t.listenerLock.Unlock()
-
-	t.listeners = append(t.listeners, listener)
-}
-
 // MinSize provides a size large enough that a terminal could technically funcion.
 func (t *Terminal) MinSize() fyne.Size {
 	s := t.guessCellSize()
+	if t.minRows > 0 && t.minCols > 0 {
+		return fyne.NewSize(s.Width*float32(t.minCols), s.Height*float32(t.minRows))
+	}
 	return fyne.NewSize(s.Width*2.5, s.Height*1.2) // just enough to get a terminal init
 }
 
@@ -128,79 +197,136 @@ func (t *Terminal) MouseDown(ev *desktop.MouseEvent) {
 	if t.hasSelectedText() {
 		t.clearSelectedText()
 	}
+	if t.openLinkAt(ev) {
+		return
+	}
 
-	if t.onMouseDown == nil {
+	down, _ := t.mouseHandlers()
+	if down == nil {
 		return
 	}
 
-	if ev.Button == desktop.MouseButtonPrimary {
-		t.onMouseDown(1, ev.Modifier, ev.Position)
-	} else if ev.Button == desktop.MouseButtonSecondary {
-		t.onMouseDown(2, ev.Modifier, ev.Position)
+	if btn := mouseButton(ev.Button); btn != 0 {
+		down(btn, ev.Modifier, ev.Position)
 	}
 }
 
 // MouseUp handles the up action for desktop mouse events.
 func (t *Terminal) MouseUp(ev *desktop.MouseEvent) {
-	if ev.Button == desktop.MouseButtonSecondary && t.hasSelectedText() {
-		t.copySelectedText(fyne.CurrentApp().Driver().AllWindows()[0].Clipboard())
+	if ev.Button == desktop.MouseButtonSecondary && t.hasSelectedText() && t.copyOnSelect {
+		if c := t.clipboard(); c != nil {
+			t.copySelectedText(c)
+		}
+		t.selStart, t.selEnd = nil, nil // the highlight has gone, so the click ends the selection
 	}
 
-	if t.onMouseDown == nil {
+	_, up := t.mouseHandlers()
+	if up == nil {
 		return
 	}
 
-	if ev.Button == desktop.MouseButtonPrimary {
-		t.onMouseUp(1, ev.Modifier, ev.Position)
-	} else if ev.Button == desktop.MouseButtonSecondary {
-		t.onMouseUp(2, ev.Modifier, ev.Position)
-	}
-}
-
-// RemoveListener de-registers a Config channel and closes it
-func (t *Terminal) RemoveListener(listener chan Config) {
-	t.listenerLock.Lock()
-	defer t.listenerLock.Unlock()
-
-	for i, l := range t.listeners {
-		if l == listener {
-			if i < len(t.listeners)-1 {
-				t.listeners = append(t.listeners[:i], t.listeners[i+1:]...)
-			} else {
-				t.listeners = t.listeners[:i]
-			}
-			close(l)
-			return
-		}
+	if btn := mouseButton(ev.Button); btn != 0 {
+		up(btn, ev.Modifier, ev.Position)
 	}
 }
 
 // Resize is called when this terminal widget has been resized.
 // It ensures that the virtual terminal is within the bounds of the widget.
 func (t *Terminal) Resize(s fyne.Size) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
 	cellSize := t.guessCellSize()
 	cols := uint(math.Floor(float64(s.Width) / float64(cellSize.Width)))
 	rows := uint(math.Floor(float64(s.Height) / float64(cellSize.Height)))
-	if (t.config.Columns == cols) && (t.config.Rows == rows) {
+	if (t.screen.config.Columns == cols) && (t.screen.config.Rows == rows) {
 		return
 	}
 
 	t.BaseWidget.Resize(s)
-	t.content.Resize(fyne.NewSize(float32(cols)*cellSize.Width, float32(rows)*cellSize.Height))
+	t.grid.Resize(fyne.NewSize(float32(cols)*cellSize.Width, float32(rows)*cellSize.Height))
+	t.setGridSize(rows, cols)
+}
 
-	oldRows := int(t.config.Rows)
-	t.config.Columns, t.config.Rows = cols, rows
-	if t.scrollBottom == 0 || t.scrollBottom == oldRows-1 {
-		t.scrollBottom = int(t.config.Rows) - 1
+// SetGridSize sets the number of rows and columns in the terminal, independent of the widget size.
+// The running program is informed of the new size. This can be called before or after the terminal is started.
+func (t *Terminal) SetGridSize(rows, cols uint) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.resizeGrid(rows, cols)
+}
+
+func (t *Terminal) resizeGrid(rows, cols uint) {
+	if (t.screen.config.Columns == cols) && (t.screen.config.Rows == rows) {
+		return
 	}
-	t.onConfigure()
 
-	go t.updatePTYSize()
+	cellSize := t.guessCellSize()
+	t.grid.Resize(fyne.NewSize(float32(cols)*cellSize.Width, float32(rows)*cellSize.Height))
+	t.setGridSize(rows, cols)
 }
 
-// SetDebug turns on output about terminal codes and other errors if the parameter is `true`.
-func (t *Terminal) SetDebug(debug bool) {
-	t.debug = debug
+// SetMinGridSize sets the smallest number of rows and columns that the terminal should be laid out with.
+// The minimum size of the widget is then large enough for that grid at the current font size, so that
+// containers do not shrink it to a few cells. Passing 0 for either returns to the default minimum.
+func (t *Terminal) SetMinGridSize(rows, cols uint) {
+	t.minRows, t.minCols = rows, cols
+	t.Refresh()
+}
+
+// setGridSize changes the size of the terminal, the state lock must be held.
+func (t *Terminal) setGridSize(rows, cols uint) {
+	t.screen.setSize(rows, cols)
+	if t.resizeCallback != nil {
+		t.resizeCallback(rows, cols)
+	}
+
+	if t.resizeDebounce <= 0 {
+		t.sizeChanged()
+		return
+	}
+	t.resizeLock.Lock()
+	defer t.resizeLock.Unlock()
+	if t.resizeTimer != nil {
+		t.resizeTimer.Stop()
+	}
+	t.resizeTimer = time.AfterFunc(t.resizeDebounce, func() {
+		t.screen.stateLock.Lock()
+		defer t.screen.stateLock.Unlock()
+		t.sizeChanged()
+	})
+}
+
+// sizeChanged tells the listeners and the running program about the current grid size.
+// The state lock must be held, as for setGridSize.
+func (t *Terminal) sizeChanged() {
+	t.screen.onConfigure()
+	if t.winchHandler != nil {
+		pxW, pxH := t.pixelSize()
+		t.winchHandler(t.screen.config.Rows, t.screen.config.Columns, pxW, pxH)
+	}
+
+	t.updatePTYSize()
+}
+
+// FontSize returns the size of text in the terminal.
+func (t *Terminal) FontSize() float32 {
+	return t.grid.CellTextSize()
+}
+
+// SetFontSize sets the size of text in the terminal, overriding the theme text size.
+// Passing 0 returns to using the theme text size. The grid will be resized to fit the new cell size.
+func (t *Terminal) SetFontSize(points float32) {
+	t.grid.TextSize = points
+	t.Refresh()
+	t.Resize(t.Size())
+}
+
+// SetLineSpacing adds extra vertical space to each row of the terminal.
+// Negative values tighten the lines, though rows will not shrink below half of the text height.
+func (t *Terminal) SetLineSpacing(extra float32) {
+	t.grid.LineSpacing = extra
+	t.Refresh()
+	t.Resize(t.Size())
 }
 
 // SetStartDir can be called before one of the Run calls to specify the initial directory.
@@ -213,64 +339,158 @@ func (t *Terminal) Tapped(ev *fyne.PointEvent) {
 	fyne.CurrentApp().Driver().CanvasForObject(t).Focus(t)
 }
 
-// Text returns the contents of the buffer as a single string joined with `\n` (no style information).
+// Text returns the contents of the rows in view as a single string joined with `\n` (no style information).
 func (t *Terminal) Text() string {
-	return t.content.Text()
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	view := grid{Rows: t.viewRows()}
+	return view.Text()
+}
+
+// TextRange returns the contents of the rows in view from startRow to endRow (inclusive) as a single string
+// joined with `\n` (no style information). Row numbers outside the view are clamped.
+func (t *Terminal) TextRange(startRow, endRow int) string {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	view := grid{Rows: t.viewRows()}
+	return view.TextRange(startRow, endRow)
+}
+
+// Cell returns the rune and style of the cell at the given row and column.
+// Rows are counted from the oldest line of scrollback followed by the screen, as in FullText.
+// The final return value is false if the position is outside the buffer.
+// The cell after a double width character, which the character covers, has a 0 rune and no style.
+func (t *Terminal) Cell(row, col int) (rune, widget.TextGridStyle, bool) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	line, ok := t.screen.bufferRow(row)
+	if !ok || col < 0 || col >= len(line.Cells) {
+		return 0, nil, false
+	}
+
+	cell := t.textGridCell(line.Cells[col].reported())
+	return cell.Rune, cell.Style, true
+}
+
+// Line returns a copy of the cells in the given row, counted as for Cell, or nil if the row is outside the buffer.
+// The cell after a double width character, which the character covers, is an empty cell.
+func (t *Terminal) Line(row int) []widget.TextGridCell {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	line, ok := t.screen.bufferRow(row)
+	if !ok {
+		return nil
+	}
+
+	cells := make([]widget.TextGridCell, len(line.Cells))
+	for i, cell := range line.Cells {
+		cells[i] = t.textGridCell(cell.reported())
+	}
+	return cells
+}
+
+// FullText returns the complete contents of the terminal, including lines that have scrolled off the screen,
+// as a single string joined with `\n`. This is suitable for saving a transcript of the session.
+func (t *Terminal) FullText() string {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	return rowsText(append(append([]gridRow{}, t.screen.scrollback...), t.screen.content.Rows...))
+}
+
+// Clear removes all content from the screen and moves the cursor to the top left.
+// When the main screen is shown the scrollback is also cleared, the alternate screen has no scrollback.
+func (t *Terminal) Clear() {
+	t.screen.stateLock.Lock()
+	t.ScrollToBottom()
+	if !t.screen.altScreen {
+		t.screen.scrollback = nil
+	}
+	rows := len(t.screen.content.Rows)
+	t.screen.content.Rows = nil
+	t.screen.content.MarkRowsDirty(0, rows-1)
+	t.screen.moveCursor(0, 0)
+	t.syncGrid()
+	t.screen.stateLock.Unlock()
+
+	t.scheduleRefresh()
+}
+
+// OnAltScreen returns true if the alternate screen, used by full screen applications, is being shown.
+func (t *Terminal) OnAltScreen() bool {
+	return t.screen.OnAltScreen()
+}
+
+// MainScreenText returns the contents of the main screen as a single string joined with `\n`.
+// While a full screen application is showing the alternate screen this is the saved content of the
+// main screen, such as the output of commands run before it started, otherwise it is the current screen.
+func (t *Terminal) MainScreenText() string {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	if t.screen.altScreen {
+		return rowsText(t.screen.mainRows)
+	}
+	return t.screen.content.Text()
 }
 
 // ExitCode returns the exit code from the terminal's shell.
 // Returns -1 if called before shell was started or before shell exited.
 // Also returns -1 if shell was terminated by a signal.
 func (t *Terminal) ExitCode() int {
-	if t.cmd == nil {
-		return -1
-	}
-	return t.cmd.ProcessState.ExitCode()
+	t.closeLock.Lock()
+	defer t.closeLock.Unlock()
+	return t.exited.ExitCode()
 }
 
 // TouchCancel handles the tap action for mobile apps that lose focus during tap.
 func (t *Terminal) TouchCancel(ev *mobile.TouchEvent) {
-	if t.onMouseUp != nil {
-		t.onMouseUp(1, 0, ev.Position)
+	if _, up := t.mouseHandlers(); up != nil {
+		up(1, 0, ev.Position)
 	}
 }
 
 // TouchDown handles the down action for mobile touch events.
 func (t *Terminal) TouchDown(ev *mobile.TouchEvent) {
-	if t.onMouseDown != nil {
-		t.onMouseDown(1, 0, ev.Position)
+	if down, _ := t.mouseHandlers(); down != nil {
+		down(1, 0, ev.Position)
 	}
 }
 
 // TouchUp handles the up action for mobile touch events.
 func (t *Terminal) TouchUp(ev *mobile.TouchEvent) {
-	if t.onMouseUp != nil {
-		t.onMouseUp(1, 0, ev.Position)
+	if _, up := t.mouseHandlers(); up != nil {
+		up(1, 0, ev.Position)
 	}
 }
 
-func (t *Terminal) onConfigure() {
-	t.listenerLock.Lock()
-	for _, l := range t.listeners {
-		select {
-		case l <- t.config:
-		default:
-			// channel blocked, might be closed
-		}
-	}
-	t.listenerLock.Unlock()
-}
-
 func (t *Terminal) open() error {
-	in, out, pty, err := t.startPTY()
+	var in io.WriteCloser
+	var out io.Reader
+	var pty io.Closer
+	var err error
+	var cancel context.CancelFunc
+	if t.ptyFactory != nil {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		in, out, pty, err = t.ptyFactory(ctx)
+	} else {
+		in, out, pty, err = t.startPTY()
+	}
 	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
 		return err
 	}
+	t.closeLock.Lock()
 	t.in = in
 	t.out = out
 	t.pty = pty
+	t.ptyCancel = cancel
+	t.closeLock.Unlock()
 
+	t.screen.stateLock.Lock()
 	t.updatePTYSize()
+	t.screen.stateLock.Unlock()
 	return nil
 }
 
@@ -280,38 +500,206 @@ func (t *Terminal) Exit() {
 	_, _ = t.Write([]byte{0x4})
 }
 
+// Close ends the session, closing the PTY or the connection passed to RunWithConnection
+// (including the reader, if it is an io.Closer), and waits for the terminal to stop reading output.
+// This allows an app to tear down a terminal without leaking its resources.
+func (t *Terminal) Close() error {
+	t.closeLock.Lock()
+	if t.closing {
+		t.closeLock.Unlock()
+		return nil
+	}
+	t.closing = true
+	done := t.runDone
+	started := t.in != nil
+	cmd := t.cmd
+	t.closeLock.Unlock()
+
+	var err error
+	if started {
+		err = t.close()
+		t.closeOutput()
+		if cmd != nil && cmd.Process != nil {
+			_ = cmd.Process.Kill() // a blocked read of the PTY only returns once the shell has gone
+		}
+	}
+	if done != nil {
+		<-done
+	}
+	return err
+}
+
+// setExited records the state of the shell once it has exited, for ExitCode.
+func (t *Terminal) setExited(state *os.ProcessState) {
+	if state == nil {
+		return
+	}
+	t.closeLock.Lock()
+	t.exited = state
+	t.closeLock.Unlock()
+}
+
+func (t *Terminal) isClosing() bool {
+	t.closeLock.Lock()
+	defer t.closeLock.Unlock()
+	return t.closing
+}
+
+// finish closes the connection once the output has ended, unless Close already did.
+func (t *Terminal) finish() error {
+	if t.isClosing() {
+		// Close may have been called before the connection was set up
+		_ = t.close()
+		t.closeOutput()
+		return nil
+	}
+	return t.close()
+}
+
+func (t *Terminal) closeOutput() {
+	t.closeLock.Lock()
+	in, out, pty := t.in, t.out, t.pty
+	t.closeLock.Unlock()
+
+	if c, ok := out.(io.Closer); ok && c != in && c != pty {
+		_ = c.Close()
+	}
+}
+
 func (t *Terminal) close() error {
-	if t.in != t.pty {
-		_ = t.in.Close() // we may already be closed
+	t.closeLock.Lock()
+	in, pty, cancel := t.in, t.pty, t.ptyCancel
+	t.closeLock.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if in != pty {
+		_ = in.Close() // we may already be closed
 	}
-	if t.pty == nil {
+	if pty == nil {
 		return nil
 	}
 
-	return t.pty.Close()
+	return pty.Close()
+}
+
+// scheduleRefresh redraws the terminal, coalescing calls so that it happens at most once per refresh interval.
+func (t *Terminal) scheduleRefresh() {
+	t.refreshLock.Lock()
+	if t.refreshPending {
+		t.refreshLock.Unlock()
+		return
+	}
+	wait := t.refreshInterval - time.Since(t.lastRefresh)
+	if wait > 0 {
+		t.refreshPending = true
+		t.refreshLock.Unlock()
+		time.AfterFunc(wait, t.refreshNow)
+		return
+	}
+	t.refreshLock.Unlock()
+
+	t.refreshNow()
+}
+
+// refreshNow redraws the terminal, holding the state lock so that output cannot change what is being drawn.
+func (t *Terminal) refreshNow() {
+	t.refreshLock.Lock()
+	t.refreshPending = false
+	t.lastRefresh = time.Now()
+	t.refreshLock.Unlock()
+
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.Refresh()
 }
 
 // don't call often - should we cache?
 func (t *Terminal) guessCellSize() fyne.Size {
-	cell := canvas.NewText("M", color.White)
-	cell.TextStyle.Monospace = true
+	return t.grid.CellSize()
+}
 
-	min := cell.MinSize()
-	return fyne.NewSize(float32(math.Round(float64(min.Width))), float32(math.Round(float64(min.Height))))
+// outputRead is the result of one read of the output, passed from the reading goroutine to run.
+type outputRead struct {
+	num int
+	err error
+}
+
+// readOutput reads the output into buf, sending each result to reads and waiting for next before reading
+// again, so that buf is not overwritten while it is handled. It returns when next is closed.
+func (t *Terminal) readOutput(buf []byte, reads chan<- outputRead, next <-chan struct{}) {
+	for {
+		num, err := t.out.Read(buf)
+		reads <- outputRead{num: num, err: err}
+		if _, ok := <-next; !ok {
+			return
+		}
+	}
+}
+
+// handleOutput passes output from the program to the screen and updates the view, the state lock must be held.
+// It returns the bytes of an incomplete character at the end of buf, to be passed again with the next data.
+func (t *Terminal) handleOutput(buf []byte) []byte {
+	if t.hasSelectedText() {
+		t.clearSelectedText()
+	}
+	if t.hoveredLink != nil {
+		t.setHoveredLink(nil) // the link may have moved
+	}
+	if t.scrollOnOutput && len(buf) > 0 {
+		t.ScrollToBottom()
+	}
+	defer t.syncGrid()
+	return t.screen.handleOutput(buf)
 }
 
 func (t *Terminal) run() {
+	done := make(chan struct{})
+	t.closeLock.Lock()
+	if t.closing {
+		t.closeLock.Unlock()
+		return
+	}
+	t.runDone = done
+	t.closeLock.Unlock()
+	defer close(done)
+
 	buf := make([]byte, bufLen)
+	reads := make(chan outputRead)
+	next := make(chan struct{})
+	defer close(next)
+	go t.readOutput(buf, reads, next)
+
 	var leftOver []byte
 	for {
-		num, err := t.out.Read(buf)
+		var read outputRead
+		select {
+		case echo := <-t.echoed:
+			// typed input shown by local echo is handled in order with the output, it is kept apart from any
+			// incomplete output waiting for the next read and holds only whole characters, so none is left over
+			t.screen.stateLock.Lock()
+			_ = t.handleOutput(echo)
+			t.screen.stateLock.Unlock()
+			t.scheduleRefresh()
+			continue
+		case read = <-reads:
+		}
+
+		num, err := read.num, read.err
 		if err != nil {
-			if t.cmd != nil {
+			t.closeLock.Lock()
+			cmd := t.cmd
+			t.closeLock.Unlock()
+			if cmd != nil {
 				// wait for cmd (shell) to exit, populates ProcessState.ExitCode
-				t.cmd.Wait()
+				_ = cmd.Wait()
+				t.setExited(cmd.ProcessState)
 			}
 			// this is the pre-go 1.13 way to check for the read failing (terminal closed)
-			if err.Error() == "EOF" {
+			if t.isClosing() {
+				break // closed by Close
+			} else if err.Error() == "EOF" {
 				break // term exit on macOS
 			} else if err, ok := err.(*os.PathError); ok && err.Err.Error() == "input/output error" {
 				break // broken pipe, terminal exit
@@ -320,22 +708,41 @@ func (t *Terminal) run() {
 			fyne.LogError("pty read error", err)
 		}
 
+		t.trace(buf[:num])
+
 		lenLeftOver := len(leftOver)
 		fullBuf := buf
 		if lenLeftOver > 0 {
 			fullBuf = append(leftOver, buf[:num]...)
 			num += lenLeftOver
 		}
-		leftOver = t.handleOutput(fullBuf[:num])
+		data := fullBuf[:num]
+		if t.outputFilter != nil {
+			data = t.outputFilter(data)
+			if data == nil {
+				leftOver = nil
+				next <- struct{}{}
+				continue
+			}
+		}
+		// copy the unprocessed bytes, the next read will overwrite our buffer
+		t.screen.stateLock.Lock()
+		leftOver = append([]byte{}, t.handleOutput(data)...)
+		t.screen.stateLock.Unlock()
+		if num > 0 {
+			t.outputActivity()
+		}
+		t.record("o", data[:len(data)-len(leftOver)])
 		if len(leftOver) == 0 {
-			t.Refresh()
+			t.scheduleRefresh()
 		}
+		next <- struct{}{}
 	}
 }
 
 // RunLocalShell starts the terminal by loading a shell and starting to process the input/output.
 func (t *Terminal) RunLocalShell() error {
-	for t.config.Columns == 0 { // don't load the TTY until our output is configured
+	for t.screen.config.Columns == 0 { // don't load the TTY until our output is configured
 		time.Sleep(time.Millisecond * 50)
 	}
 	err := t.open()
@@ -345,30 +752,194 @@ func (t *Terminal) RunLocalShell() error {
 
 	t.run()
 
-	return t.close()
+	return t.finish()
 }
 
 // RunWithConnection starts the terminal by connecting to an external resource like an SSH connection.
 func (t *Terminal) RunWithConnection(in io.WriteCloser, out io.Reader) error {
-	for t.config.Columns == 0 { // don't load the TTY until our output is configured
+	for t.screen.config.Columns == 0 { // don't load the TTY until our output is configured
 		time.Sleep(time.Millisecond * 50)
 	}
+	t.closeLock.Lock()
 	t.in = in
 	t.out = out
+	t.closeLock.Unlock()
 
 	t.run()
 
-	return t.close()
+	return t.finish()
+}
+
+// PTYFactory creates the connection that RunLocalShell reads from and writes to.
+// The closer is closed when the session ends and the context is cancelled at the same time.
+type PTYFactory func(ctx context.Context) (in io.WriteCloser, out io.Reader, closer io.Closer, err error)
+
+// SetPTYFactory sets a function that RunLocalShell calls to start the session instead of running $SHELL in a local PTY.
+// This can provide a mock PTY for testing, or a backend such as a container exec.
+// If the closer is not a local PTY the terminal cannot resize it, so use SetWinchHandler to pass on size changes.
+// Passing nil restores the default.
+func (t *Terminal) SetPTYFactory(factory PTYFactory) {
+	t.ptyFactory = factory
+}
+
+// SetResizeCallback sets a function to call when the number of rows or columns in the terminal changes.
+// Unlike the listeners added with AddListener it is not called for other configuration changes such as the title.
+// The callback is run while the terminal is being resized, so it must not call SetGridSize.
+func (t *Terminal) SetResizeCallback(callback func(rows, cols uint)) {
+	t.resizeCallback = callback
+}
+
+// SetWinchHandler sets a function to call with the new window size whenever the rows or columns change.
+// This allows connections made with RunWithConnection, such as SSH, to tell the remote end about the size,
+// as the local PTY is only resized when the terminal started its own shell.
+func (t *Terminal) SetWinchHandler(handler func(rows, cols uint, pxW, pxH uint16)) {
+	t.winchHandler = handler
+}
+
+// SetResizeDebounce delays telling the running program, the winch handler and the listeners about a new
+// size until it has not changed for the given duration, so that dragging a window edge does not send a
+// storm of size changes. The grid and the resize callback still follow the size as it changes.
+// The default is 0, which reports every change straight away.
+func (t *Terminal) SetResizeDebounce(d time.Duration) {
+	t.resizeDebounce = d
+}
+
+// pixelSize returns the size of the terminal grid in device pixels.
+func (t *Terminal) pixelSize() (uint16, uint16) {
+	scale := float32(1.0)
+	if app := fyne.CurrentApp(); app != nil {
+		if c := app.Driver().CanvasForObject(t); c != nil {
+			scale = c.Scale()
+		}
+	}
+
+	size := t.grid.Size()
+	return uint16(size.Width * scale), uint16(size.Height * scale)
+}
+
+// SetBellHandler sets a function to call when the terminal bell rings, for example to play a sound.
+// The handler is called on a background goroutine.
+func (t *Terminal) SetBellHandler(handler func()) {
+	t.bellHandler = handler
+}
+
+// SetVisualBell sets whether the whole terminal should briefly flash inverted colours when the bell rings.
+// The cursor is tinted to show the bell in either case.
+func (t *Terminal) SetVisualBell(visual bool) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.visualBell = visual
+}
+
+// SetMaxRefreshRate sets the maximum number of times per second that the terminal is redrawn as output arrives.
+// Output received between frames is coalesced into a single refresh. A rate of 0 refreshes after every read.
+func (t *Terminal) SetMaxRefreshRate(fps int) {
+	t.refreshLock.Lock()
+	defer t.refreshLock.Unlock()
+	if fps <= 0 {
+		t.refreshInterval = 0
+		return
+	}
+	t.refreshInterval = time.Second / time.Duration(fps)
+}
+
+// SetAltSendsEscape sets whether pressing a key with Alt sends ESC followed by the key, so that
+// Alt+f sends `ESC f`. This matches xterm's metaSendsEscape and is used by readline and Emacs.
+// The default is false.
+func (t *Terminal) SetAltSendsEscape(enabled bool) {
+	t.altSendsEscape = enabled
+}
+
+// SetBackspaceSendsDelete sets whether the Backspace key sends DEL (0x7f), matching `stty erase ^?`,
+// or BS (0x08) if false. The default is true. Applications can also change this using DECBKM.
+func (t *Terminal) SetBackspaceSendsDelete(del bool) {
+	t.screen.backarrowSendsBS = !del
+}
+
+// CursorVisible returns true if the text cursor is shown.
+func (t *Terminal) CursorVisible() bool {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	return !t.screen.cursorHidden
+}
+
+// SetCursorVisible shows or hides the text cursor. Applications can also change this using DECTCEM.
+func (t *Terminal) SetCursorVisible(visible bool) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.screen.cursorHidden = !visible
+	t.refreshCursor()
+}
+
+// MoveCursorTo moves the text cursor to the given row and column, counting from 0 at the top left.
+// Positions outside the terminal are moved to the nearest edge, and the second half of a double width
+// character moves the cursor to the character.
+func (t *Terminal) MoveCursorTo(row, col int) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.screen.moveCursorToCell(row, col)
+}
+
+// LocalEcho returns true if typed characters are shown by the terminal as well as being sent.
+func (t *Terminal) LocalEcho() bool {
+	return t.localEcho
+}
+
+// SetLocalEcho sets whether typed characters are shown by the terminal itself. The default is false.
+// Shells started by the terminal are echoed by the PTY, as are most remote shells, so turning this on
+// for them would show input twice. It is intended for connections passed to RunWithConnection that do
+// not echo. When it is off nothing is shown unless the other end echoes, so the PTY or remote echo
+// setting controls whether input such as a password is visible.
+func (t *Terminal) SetLocalEcho(echo bool) {
+	t.localEcho = echo
+}
+
+// Title returns the current title of the terminal.
+func (t *Terminal) Title() string {
+	return t.screen.Title()
+}
+
+// SetOutputFilter sets a function that can observe or rewrite the output read from the connection
+// before it is processed. It is called with each chunk read, including any bytes left over from an
+// incomplete sequence in the previous chunk. Returning nil drops the chunk.
+func (t *Terminal) SetOutputFilter(filter func(in []byte) []byte) {
+	t.outputFilter = filter
 }
 
 // Write is used to send commands into an open terminal connection.
-// Errors will be returned if the connection is not established, has closed, or there was a problem in transmission.
+// An error matching ErrTerminalClosed is returned in three cases: the connection is not established yet,
+// it has closed, or the write failed after the session ended. Any other problem in transmission is returned as is.
 func (t *Terminal) Write(b []byte) (int, error) {
-	if t.in == nil {
-		return 0, io.EOF
+	t.closeLock.Lock()
+	in := t.in
+	t.closeLock.Unlock()
+	if in == nil {
+		return 0, closedError{io.EOF}
+	}
+
+	t.record("i", b)
+	n, err := in.Write(b)
+	if err != nil && (errors.Is(err, os.ErrClosed) || errors.Is(err, io.ErrClosedPipe) || t.ended()) {
+		err = closedError{err}
+	}
+	return n, err
+}
+
+// ended returns true if the terminal has been closed or has stopped reading output.
+func (t *Terminal) ended() bool {
+	t.closeLock.Lock()
+	closing, done := t.closing, t.runDone
+	t.closeLock.Unlock()
+	if closing || done == nil {
+		return closing
 	}
 
-	return t.in.Write(b)
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
 }
 
 func (t *Terminal) setupShortcuts() {
@@ -379,23 +950,9 @@ func (t *Terminal) setupShortcuts() {
 	}
 	t.ShortcutHandler.AddShortcut(paste,
 		func(_ fyne.Shortcut) {
-			a := fyne.CurrentApp()
-			c := a.Driver().CanvasForObject(t)
-			if c == nil {
-				return
-			}
-
-			var win fyne.Window
-			for _, w := range a.Driver().AllWindows() {
-				if w.Canvas() == c {
-					win = w
-				}
-			}
-			if win == nil {
-				return
+			if c := t.clipboard(); c != nil {
+				t.pasteText(c)
 			}
-
-			t.pasteText(win.Clipboard())
 		})
 	var shortcutCopy fyne.Shortcut
 	shortcutCopy = &desktop.CustomShortcut{KeyName: fyne.KeyC, Modifier: fyne.KeyModifierShift | fyne.KeyModifierShortcutDefault}
@@ -405,24 +962,32 @@ func (t *Terminal) setupShortcuts() {
 
 	t.ShortcutHandler.AddShortcut(shortcutCopy,
 		func(_ fyne.Shortcut) {
-			a := fyne.CurrentApp()
-			c := a.Driver().CanvasForObject(t)
-			if c == nil {
-				return
+			if c := t.clipboard(); c != nil {
+				t.copySelectedText(c)
 			}
+		})
 
-			var win fyne.Window
-			for _, w := range a.Driver().AllWindows() {
-				if w.Canvas() == c {
-					win = w
-				}
-			}
-			if win == nil {
-				return
-			}
+	var shortcutClear fyne.Shortcut
+	shortcutClear = &desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierShift | fyne.KeyModifierShortcutDefault}
+	if runtime.GOOS == "darwin" {
+		shortcutClear = &desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierShortcutDefault}
+	}
+	t.SetClearShortcut(shortcutClear)
+	t.SetCopyModeShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyX, Modifier: fyne.KeyModifierShift | fyne.KeyModifierShortcutDefault})
+}
 
-			t.copySelectedText(win.Clipboard())
+// SetClearShortcut sets the shortcut that clears the terminal, replacing the default.
+// The default is Cmd+K on macOS and Ctrl+Shift+K elsewhere. Pass nil to remove the shortcut.
+func (t *Terminal) SetClearShortcut(s fyne.Shortcut) {
+	if t.clearShortcut != nil {
+		t.ShortcutHandler.RemoveShortcut(t.clearShortcut)
+	}
+	t.clearShortcut = s
+	if s != nil {
+		t.ShortcutHandler.AddShortcut(s, func(_ fyne.Shortcut) {
+			t.Clear()
 		})
+	}
 }
 
 func (t *Terminal) startingDir() string {
@@ -439,16 +1004,126 @@ func (t *Terminal) startingDir() string {
 // New sets up a new terminal instance with the bash shell
 func New() *Terminal {
 	t := &Terminal{
-		mouseCursor:      desktop.DefaultCursor,
-		highlightBitMask: 0x55,
+		screen:                newScreen(),
+		mouseCursor:           desktop.DefaultCursor,
+		highlightBitMask:      0x55,
+		cursorHollowUnfocused: true,
+		cursorBlinkEnabled:    true,
+		cursorBlinkRate:       cursorBlinkInterval,
+		refreshInterval:       time.Second / defaultRefreshRate,
+		scrollOnKeystroke:     true,
+		copyOnSelect:          true,
 	}
 	t.ExtendBaseWidget(t)
-	t.content = widget2.NewTermGrid()
+	t.echoed = make(chan []byte)
+	t.grid = widget2.NewTermGrid()
 	t.setupShortcuts()
 
+	t.screen.replies = t
+	t.screen.bellRung = t.flashBell
+	t.screen.resize = t.resizeGrid
+	t.screen.scrolledOff = func() {
+		if t.scrollOffset > 0 {
+			t.scrollOffset++ // keep the same lines in view
+		}
+	}
+	t.screen.cellSize = func() (float32, float32) {
+		cell := t.guessCellSize()
+		return cell.Width, cell.Height
+	}
+	t.screen.defaultColors = func() (color.Color, color.Color) {
+		return theme.ForegroundColor(), theme.DisabledButtonColor()
+	}
+	t.screen.runAPCHandler = func(handler APCHandler, arg string) {
+		handler(t, arg)
+	}
 	return t
 }
 
+// SetTitle sets the title and notifies listeners, as if the application had set it using OSC 2.
+func (t *Terminal) SetTitle(title string) {
+	t.screen.SetTitle(title)
+}
+
+// AddListener registers a new outgoing channel that will have our Config sent each time it changes.
+func (t *Terminal) AddListener(listener chan Config) {
+	t.screen.AddListener(listener)
+}
+
+// RemoveListener de-registers a Config channel and closes it
+func (t *Terminal) RemoveListener(listener chan Config) {
+	t.screen.RemoveListener(listener)
+}
+
+// SetDebug turns on output about terminal codes and other errors if the parameter is `true`.
+func (t *Terminal) SetDebug(debug bool) {
+	t.screen.SetDebug(debug)
+}
+
+// SetAllowColumnModeSwitch sets whether applications may switch between 80 and 132 columns using DECCOLM.
+// The default is false, so that the number of columns follows the size of the widget.
+func (t *Terminal) SetAllowColumnModeSwitch(allow bool) {
+	t.screen.SetAllowColumnModeSwitch(allow)
+}
+
+// SetTabWidth sets the number of columns between tab stops, the default is 8.
+// The width is limited to between 1 and 32 and applies to output received after it is set.
+func (t *Terminal) SetTabWidth(n int) {
+	t.screen.SetTabWidth(n)
+}
+
+// SetSpecialGraphicsMap replaces some or all of the characters shown for the DEC special graphics
+// character set, which programs use for line drawing. Each key is the ASCII character sent by the program
+// and the value is the character to show, characters that are not in the map keep their default.
+// Pass nil to return to the default Unicode characters.
+func (t *Terminal) SetSpecialGraphicsMap(m map[rune]rune) {
+	t.screen.SetSpecialGraphicsMap(m)
+}
+
+// SetASCIIBoxDrawing sets whether the DEC special graphics are shown using ASCII approximations,
+// such as '+', '-' and '|' for boxes, instead of Unicode line drawing characters.
+// This is useful with fonts that draw the Unicode characters poorly. It replaces any map set using
+// SetSpecialGraphicsMap, and turning it off returns to the default characters.
+func (t *Terminal) SetASCIIBoxDrawing(ascii bool) {
+	t.screen.SetASCIIBoxDrawing(ascii)
+}
+
+// SetMaxStringLength sets the longest OSC, APC or DCS string, in bytes, that will be accepted.
+// A longer string is discarded, up to its terminator, so that a program that never terminates
+// one cannot use unlimited memory. The default is 1MB, and 0 removes the limit.
+func (t *Terminal) SetMaxStringLength(n int) {
+	t.screen.SetMaxStringLength(n)
+}
+
+// SetMaxControlSequenceLength sets the longest parameter string, in bytes, of a control sequence such as CSI.
+// A longer sequence is abandoned and printed as text, as it is most likely not meant to be a sequence.
+// The default is 64, and 0 removes the limit.
+func (t *Terminal) SetMaxControlSequenceLength(n int) {
+	t.screen.SetMaxControlSequenceLength(n)
+}
+
+// SetC1Controls sets whether the 8-bit C1 control characters, 0x80 to 0x9f, are recognised as the
+// equivalent escape sequence, for example 0x9b as CSI, for programs that use an 8-bit encoding.
+// The default is false, as in UTF-8 output these are shown as text. The 8-bit string terminator
+// (0x9c) always ends an OSC, APC or DCS string.
+func (t *Terminal) SetC1Controls(enabled bool) {
+	t.screen.SetC1Controls(enabled)
+}
+
+// ScrollRegion returns the first and last rows, counted from 0, of the area that scrolls as lines are added.
+// Unless a region has been set this is the whole screen.
+func (t *Terminal) ScrollRegion() (top, bottom int) {
+	return t.screen.ScrollRegion()
+}
+
+// SetScrollRegion sets the first and last rows, counted from 0, of the area that scrolls as lines are added,
+// in the same way as the DECSTBM escape sequence. Rows outside the region stay in place, which can be used
+// to keep status lines at the top or bottom of the screen. The cursor is moved to the home position.
+// A region that does not contain at least two rows is ignored.
+func (t *Terminal) SetScrollRegion(top, bottom int) {
+	t.screen.SetScrollRegion(top, bottom)
+}
+
 // sanitizePosition ensures that the given position p is within the bounds of the terminal.
 // If the position is outside the bounds, it adjusts the coordinates to the nearest valid values.
 // The adjusted position is then returned.
@@ -472,6 +1147,8 @@ func (t *Terminal) sanitizePosition(p fyne.Position) *fyne.Position {
 
 // Dragged is called by fyne when the left mouse is down and moved whilst over the widget.
 func (t *Terminal) Dragged(d *fyne.DragEvent) {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
 	pos := t.sanitizePosition(d.Position)
 	if !t.selecting {
 		if t.keyboardState.altPressed {
@@ -482,8 +1159,7 @@ func (t *Terminal) Dragged(d *fyne.DragEvent) {
 		t.selEnd = nil
 	}
 	// clear any previous selection
-	sr, sc, er, ec := t.getSelectedRange()
-	widget2.ClearHighlightRange(t.content, t.blockMode, sr, sc, er, ec)
+	t.clearHighlight()
 
 	// make sure that x,y,x1,y1 are always positive
 	t.selecting = true
@@ -491,9 +1167,21 @@ func (t *Terminal) Dragged(d *fyne.DragEvent) {
 	p := t.getTermPosition(*pos)
 	t.selEnd = &p
 	t.highlightSelectedText()
+
+	switch {
+	case d.Position.Y < 0:
+		t.startDragScroll(1)
+	case d.Position.Y > t.Size().Height:
+		t.startDragScroll(-1)
+	default:
+		t.stopDragScroll()
+	}
 }
 
 // DragEnd is called by fyne when the left mouse is released after a Drag event.
 func (t *Terminal) DragEnd() {
+	t.screen.stateLock.Lock()
+	defer t.screen.stateLock.Unlock()
+	t.stopDragScroll()
 	t.selecting = false
 }
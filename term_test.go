@@ -1,13 +1,21 @@
 package terminal
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	_ "fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +23,7 @@ import (
 func TestNewTerminal(t *testing.T) {
 	term := New()
 	assert.NotNil(t, term)
-	assert.NotNil(t, term.content)
+	assert.NotNil(t, term.screen.content)
 }
 
 func TestExitCode(t *testing.T) {
@@ -44,28 +52,279 @@ func TestExitCode01(t *testing.T) {
 	testExitCodeN(t, 1)
 }
 
+func TestTerminal_Close(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 2
+	before := runtime.NumGoroutine()
+	r, w := io.Pipe()
+	defer w.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- term.RunWithConnection(NopCloser(&bytes.Buffer{}), r)
+	}()
+	for started := false; !started; {
+		time.Sleep(10 * time.Millisecond)
+		term.closeLock.Lock()
+		started = term.runDone != nil
+		term.closeLock.Unlock()
+	}
+
+	assert.Nil(t, term.Close())
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("RunWithConnection did not return after Close")
+	}
+	assert.Nil(t, term.Close()) // a second close does nothing
+
+	for i := 0; i < 50 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+type closeRecorder bool
+
+func (c *closeRecorder) Close() error {
+	*c = true
+	return nil
+}
+
+func TestTerminal_SetPTYFactory(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 2
+	in := &bytes.Buffer{}
+	r, w := io.Pipe()
+	closer := new(closeRecorder)
+	var ctx context.Context
+	term.SetPTYFactory(func(c context.Context) (io.WriteCloser, io.Reader, io.Closer, error) {
+		ctx = c
+		return NopCloser(in), r, closer, nil
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- term.RunLocalShell()
+	}()
+	text := func() string {
+		term.screen.stateLock.Lock()
+		defer term.screen.stateLock.Unlock()
+		return term.screen.content.Text()
+	}
+	_, _ = w.Write([]byte("hi"))
+	assert.Eventually(t, func() bool { return text() == "hi" }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, -1, term.ProcessPID())
+	term.SetGridSize(3, 10) // not a local PTY, must not be resized
+
+	_, err := term.Write([]byte("ls"))
+	assert.Nil(t, err)
+	assert.Equal(t, "ls", in.String())
+
+	assert.Nil(t, term.Close())
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("RunLocalShell did not return after Close")
+	}
+	assert.True(t, bool(*closer))
+	assert.NotNil(t, ctx.Err())
+}
+
+func TestTerminal_SetPTYFactory_Error(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 5, 2
+	failed := errors.New("no backend")
+	term.SetPTYFactory(func(context.Context) (io.WriteCloser, io.Reader, io.Closer, error) {
+		return nil, nil, nil, failed
+	})
+
+	assert.Equal(t, failed, term.RunLocalShell())
+}
+
+func TestTerminal_CloseDuringOutput(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		term := New()
+		term.screen.config.Columns, term.screen.config.Rows = 5, 2
+		r, w := io.Pipe()
+		go func() {
+			for {
+				if _, err := w.Write([]byte("hello\x1b[2Jworld\r\n")); err != nil {
+					return // the terminal has closed the pipe
+				}
+			}
+		}()
+
+		done := make(chan error)
+		go func() {
+			done <- term.RunWithConnection(NopCloser(&bytes.Buffer{}), r)
+		}()
+		time.Sleep(time.Millisecond)
+		assert.Nil(t, term.Close())
+		<-done
+		assert.NotNil(t, term.screen.content) // the grid outlives the session
+	}
+}
+
+type busyWriter struct{}
+
+func (busyWriter) Write([]byte) (int, error) {
+	return 0, errors.New("busy")
+}
+
+func TestTerminal_WriteClosed(t *testing.T) {
+	term := New()
+	_, err := term.Write([]byte("ls"))
+	assert.True(t, errors.Is(err, ErrTerminalClosed))
+	assert.True(t, errors.Is(err, io.EOF))
+
+	r, w := io.Pipe()
+	_ = r.Close()
+	term.in = w
+	_, err = term.Write([]byte("ls"))
+	assert.True(t, errors.Is(err, ErrTerminalClosed))
+	assert.True(t, errors.Is(err, io.ErrClosedPipe))
+
+	term.in = NopCloser(busyWriter{})
+	_, err = term.Write([]byte("ls"))
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrTerminalClosed))
+}
+
 func TestTerminal_Resize(t *testing.T) {
 	term := New()
 	term.Resize(fyne.NewSize(45, 45))
 
-	assert.Equal(t, uint(5), term.config.Columns)
-	assert.Equal(t, uint(2), term.config.Rows)
+	assert.Equal(t, uint(5), term.screen.config.Columns)
+	assert.Equal(t, uint(2), term.screen.config.Rows)
+}
+
+func TestTerminal_SetResizeCallback(t *testing.T) {
+	term := New()
+	calls := 0
+	var rows, cols uint
+	term.SetResizeCallback(func(r, c uint) {
+		calls++
+		rows, cols = r, c
+	})
+
+	term.Resize(fyne.NewSize(45, 45))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, uint(2), rows)
+	assert.Equal(t, uint(5), cols)
+
+	term.Resize(fyne.NewSize(46, 46)) // same grid size
+	assert.Equal(t, 1, calls)
+
+	term.screen.config.Title = "changed"
+	term.screen.onConfigure()
+	assert.Equal(t, 1, calls)
+}
+
+func TestTerminal_SetGridSize(t *testing.T) {
+	term := New()
+	listen := make(chan Config, 1)
+	term.AddListener(listen)
+
+	term.SetGridSize(24, 80)
+	assert.Equal(t, uint(24), term.screen.config.Rows)
+	assert.Equal(t, uint(80), term.screen.config.Columns)
+	assert.Equal(t, 23, term.screen.scrollBottom)
+	conf := <-listen
+	assert.Equal(t, uint(80), conf.Columns)
+}
+
+func TestTerminal_SetWinchHandler(t *testing.T) {
+	term := New()
+	var rows, cols uint
+	var pxW, pxH uint16
+	term.SetWinchHandler(func(r, c uint, w, h uint16) {
+		rows, cols = r, c
+		pxW, pxH = w, h
+	})
+
+	term.SetGridSize(24, 80)
+	assert.Equal(t, uint(24), rows)
+	assert.Equal(t, uint(80), cols)
+	assert.NotZero(t, pxW)
+	assert.NotZero(t, pxH)
+}
+
+func TestTerminal_SetMinGridSize(t *testing.T) {
+	term := New()
+	cell := term.guessCellSize()
+	assert.Less(t, term.MinSize().Width, cell.Width*3)
+
+	term.SetMinGridSize(5, 20)
+	assert.Equal(t, fyne.NewSize(cell.Width*20, cell.Height*5), term.MinSize())
+
+	term.SetMinGridSize(0, 0)
+	assert.Less(t, term.MinSize().Width, cell.Width*3)
+}
+
+func TestTerminal_SetResizeDebounce(t *testing.T) {
+	term := New()
+	resized := 0
+	term.SetResizeCallback(func(uint, uint) {
+		resized++
+	})
+	winch := make(chan uint, 5)
+	term.SetWinchHandler(func(r, _ uint, _, _ uint16) {
+		winch <- r
+	})
+
+	term.SetResizeDebounce(20 * time.Millisecond)
+	term.SetGridSize(10, 80)
+	term.SetGridSize(11, 80)
+	term.SetGridSize(12, 80)
+	assert.Equal(t, 3, resized)
+	assert.Equal(t, uint(12), term.screen.config.Rows)
+	assert.Len(t, winch, 0)
+
+	assert.Equal(t, uint(12), <-winch)
+	time.Sleep(40 * time.Millisecond)
+	assert.Len(t, winch, 0) // only the settled size is sent
+}
+
+func TestTerminal_SetGridSizeDuringOutput(t *testing.T) {
+	term := New()
+	term.SetGridSize(5, 20)
+	term.SetResizeDebounce(time.Millisecond)
+	r, w := io.Pipe()
+	go func() {
+		for i := 0; i < 200; i++ {
+			_, _ = w.Write([]byte("0123456789abcdefghij\r\n" + esc("[2;3H") + esc("[J")))
+		}
+		_ = w.Close()
+	}()
+
+	done := make(chan error)
+	go func() {
+		done <- term.RunWithConnection(NopCloser(&bytes.Buffer{}), r)
+	}()
+	for i := 0; i < 20; i++ {
+		term.SetGridSize(uint(5+i%3), 20)
+		time.Sleep(time.Millisecond)
+	}
+	assert.Nil(t, <-done)
 }
 
 func TestTerminal_AddListener(t *testing.T) {
 	term := New()
 	listen := make(chan Config, 1)
 	term.AddListener(listen)
-	assert.Equal(t, 1, len(term.listeners))
+	assert.Equal(t, 1, len(term.screen.listeners))
 
-	go term.onConfigure()
+	go term.screen.onConfigure()
 	select {
 	case <-listen: // passed
 	case <-time.After(time.Millisecond * 100):
 		t.Error("Failed waiting for configure callback")
 	}
 	term.RemoveListener(listen)
-	assert.Equal(t, 0, len(term.listeners))
+	assert.Equal(t, 0, len(term.screen.listeners))
 }
 
 func TestTerminal_SanitizePosition(t *testing.T) {
@@ -126,3 +385,282 @@ func TestTerminal_SanitizePosition(t *testing.T) {
 		})
 	}
 }
+
+func TestTerminal_SetFontSize(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 200))
+	cols, rows := term.screen.config.Columns, term.screen.config.Rows
+	normal := term.guessCellSize()
+
+	term.SetFontSize(term.FontSize() * 2)
+	assert.Greater(t, term.guessCellSize().Width, normal.Width)
+	assert.Greater(t, term.guessCellSize().Height, normal.Height)
+	assert.Less(t, term.screen.config.Columns, cols)
+	assert.Less(t, term.screen.config.Rows, rows)
+
+	term.SetFontSize(0)
+	assert.Equal(t, theme.TextSize(), term.FontSize())
+	assert.Equal(t, cols, term.screen.config.Columns)
+}
+
+func TestTerminal_SetLineSpacing(t *testing.T) {
+	term := New()
+	normal := term.guessCellSize()
+
+	term.SetLineSpacing(10)
+	assert.Equal(t, normal.Width, term.guessCellSize().Width)
+	assert.Equal(t, normal.Height+10, term.guessCellSize().Height)
+
+	term.SetLineSpacing(-normal.Height)
+	assert.Less(t, term.guessCellSize().Height, normal.Height)
+	assert.GreaterOrEqual(t, term.guessCellSize().Height, normal.Height/2-1)
+}
+
+func TestTerminal_TextRange(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 3
+	term.screen.scrollBottom = 2
+	term.handleOutput([]byte("one\r\ntwo\r\nthree"))
+
+	assert.Equal(t, "one\ntwo\nthree", term.FullText())
+	assert.Equal(t, "two\nthree", term.TextRange(1, 2))
+	assert.Equal(t, "one\ntwo", term.TextRange(-1, 1))
+	assert.Equal(t, "three", term.TextRange(2, 10))
+	assert.Equal(t, "", term.TextRange(2, 1))
+}
+
+func TestTerminal_CellAndLine(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("ab\r\n" + esc("[31m") + "c"))
+
+	r, style, ok := term.Cell(0, 1)
+	assert.True(t, ok)
+	assert.Equal(t, 'b', r)
+	assert.Nil(t, style.TextColor())
+
+	r, style, ok = term.Cell(1, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 'c', r)
+	assert.Equal(t, basicColors[1], style.TextColor())
+
+	_, _, ok = term.Cell(0, 5)
+	assert.False(t, ok)
+	_, _, ok = term.Cell(-1, 0)
+	assert.False(t, ok)
+
+	line := term.Line(0)
+	assert.Len(t, line, 5)
+	line[0].Rune = 'z'
+	r, _, _ = term.Cell(0, 0)
+	assert.Equal(t, 'a', r)
+	assert.Nil(t, term.Line(2))
+
+	term.handleOutput([]byte("\r\nd"))
+	r, _, ok = term.Cell(0, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 'a', r) // now in the scrollback
+	r, _, ok = term.Cell(2, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 'd', r)
+	assert.Len(t, term.Line(1), 5)
+	assert.Nil(t, term.Line(3))
+	_, _, ok = term.Cell(3, 0)
+	assert.False(t, ok)
+}
+
+func TestTerminal_CellAndLine_WideChar(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 4
+	term.screen.config.Rows = 1
+	term.handleOutput([]byte("世a"))
+
+	r, _, ok := term.Cell(0, 0)
+	assert.True(t, ok)
+	assert.Equal(t, '世', r)
+	r, style, ok := term.Cell(0, 1)
+	assert.True(t, ok)
+	assert.Equal(t, rune(0), r) // covered by the wide character
+	assert.Nil(t, style)
+
+	line := term.Line(0)
+	assert.Equal(t, '世', line[0].Rune)
+	assert.Equal(t, widget.TextGridCell{}, line[1])
+	assert.Equal(t, 'a', line[2].Rune)
+}
+
+func TestTerminal_SetOutputFilter(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 20
+	term.screen.config.Rows = 2
+	term.SetOutputFilter(func(in []byte) []byte {
+		if bytes.Contains(in, []byte("drop")) {
+			return nil
+		}
+		return bytes.ReplaceAll(in, []byte("secret"), []byte("******"))
+	})
+
+	_ = term.RunWithConnection(NopCloser(&bytes.Buffer{}), strings.NewReader("my secret"))
+	assert.Equal(t, "my ******", term.Text())
+
+	_ = term.RunWithConnection(NopCloser(&bytes.Buffer{}), strings.NewReader("drop"))
+	assert.Equal(t, "my ******", term.Text())
+}
+
+func TestTerminal_SetMaxRefreshRate(t *testing.T) {
+	term := New()
+	term.SetMaxRefreshRate(10)
+
+	term.scheduleRefresh()
+	first := term.lastRefresh
+	assert.False(t, first.IsZero())
+	term.scheduleRefresh()
+	term.scheduleRefresh()
+	assert.True(t, term.refreshPending)
+	assert.Equal(t, first, term.lastRefresh)
+
+	assert.Eventually(t, func() bool {
+		term.refreshLock.Lock()
+		defer term.refreshLock.Unlock()
+		return !term.refreshPending
+	}, time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, term.lastRefresh.Sub(first), 100*time.Millisecond)
+
+	term.SetMaxRefreshRate(0)
+	term.scheduleRefresh()
+	assert.False(t, term.refreshPending)
+}
+
+func TestTerminal_RefreshDuringOutput(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 20, 5
+	term.screen.scrollBottom = 4
+	term.Refresh()
+	term.SetMaxRefreshRate(1000)
+
+	line := "0123456789" + esc("[31m") + "abc" + esc("[0m") + "\r\n"
+	r, w := io.Pipe()
+	go func() {
+		for i := 0; i < 500; i++ {
+			_, _ = w.Write([]byte(line)) // separate writes so that the timed refreshes run between them
+		}
+		_ = w.Close()
+	}()
+	_ = term.RunWithConnection(NopCloser(&bytes.Buffer{}), r)
+	assert.Equal(t, "0123456789abc", term.TextRange(3, 3))
+}
+
+func BenchmarkTerminal_Run(b *testing.B) {
+	line := strings.Repeat("0123456789", 7) + esc("[31m") + "abcdefgh" + esc("[0m") + "\r\n"
+	data := []byte(strings.Repeat(line, 50*1024*1024/len(line)))
+	term := New()
+	term.screen.config.Columns = 80
+	term.screen.config.Rows = 50
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = term.RunWithConnection(NopCloser(&bytes.Buffer{}), bytes.NewReader(data))
+	}
+}
+
+func TestTerminal_Clear(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	term.screen.scrollBottom = 1
+	term.handleOutput([]byte("a\r\nb\r\nc"))
+	assert.Equal(t, 1, len(term.screen.scrollback))
+
+	term.Clear()
+	assert.Equal(t, "", term.FullText())
+	assert.Equal(t, 0, term.screen.cursorRow)
+	assert.Equal(t, 0, term.screen.cursorCol)
+
+	term.screen.stateLock.Lock() // Clear schedules a redraw, which holds the lock
+	term.handleOutput([]byte("d\r\ne\r\nf" + esc("[?1049h") + "vi"))
+	term.screen.stateLock.Unlock()
+	term.Clear()
+	assert.Equal(t, "", term.Text())
+	term.screen.stateLock.Lock()
+	assert.Equal(t, 1, len(term.screen.scrollback)) // the alternate screen has its own content
+	term.handleOutput([]byte(esc("[?1049l")))
+	term.screen.stateLock.Unlock()
+	assert.Equal(t, "d\ne\nf", term.FullText())
+}
+
+func TestTerminal_SetClearShortcut(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 2
+	shortcut := &desktop.CustomShortcut{KeyName: fyne.KeyL, Modifier: fyne.KeyModifierAlt | fyne.KeyModifierShift}
+	term.SetClearShortcut(shortcut)
+
+	term.handleOutput([]byte("a"))
+	term.TypedShortcut(shortcut)
+	assert.Equal(t, "", term.screen.content.Text())
+
+	term.SetClearShortcut(nil)
+	term.handleOutput([]byte("a"))
+	term.TypedShortcut(shortcut)
+	assert.Equal(t, "a", term.screen.content.Text())
+}
+
+func TestTerminal_CursorControl(t *testing.T) {
+	term := New()
+	term.screen.config.Columns = 5
+	term.screen.config.Rows = 3
+	assert.True(t, term.CursorVisible())
+
+	term.SetCursorVisible(false)
+	assert.False(t, term.CursorVisible())
+	term.handleOutput([]byte(esc("[?25h")))
+	assert.True(t, term.CursorVisible())
+
+	term.MoveCursorTo(1, 2)
+	assert.Equal(t, 1, term.screen.cursorRow)
+	assert.Equal(t, 2, term.screen.cursorCol)
+	term.MoveCursorTo(10, -1)
+	assert.Equal(t, 2, term.screen.cursorRow)
+	assert.Equal(t, 0, term.screen.cursorCol)
+}
+
+func TestTerminal_CursorControl_WhileOutput(t *testing.T) {
+	term := New()
+	term.screen.config.Columns, term.screen.config.Rows = 10, 3
+	r, w := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- term.RunWithConnection(NopCloser(&bytes.Buffer{}), r)
+	}()
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < 100; i++ {
+			_, _ = w.Write([]byte("out\r\n" + esc("[?25l") + esc("[2;3H") + esc("[?25h")))
+		}
+	}()
+
+	streaming := true
+	for i := 0; streaming; i++ {
+		term.MoveCursorTo(i%3, i%10)
+		term.SetCursorVisible(i%2 == 0)
+		_ = term.CursorVisible()
+		select {
+		case <-written:
+			streaming = false
+		default:
+		}
+	}
+
+	assert.Nil(t, term.Close())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunWithConnection did not return after Close")
+	}
+}
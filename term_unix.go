@@ -8,22 +8,23 @@ import (
 	"os"
 	"os/exec"
 
-	"fyne.io/fyne/v2"
 	"github.com/creack/pty"
 )
 
 func (t *Terminal) updatePTYSize() {
-	if t.pty == nil { // SSH or other direct connection?
+	t.closeLock.Lock()
+	p := t.pty
+	t.closeLock.Unlock()
+	if p == nil { // SSH or other direct connection?
 		return
 	}
-	scale := float32(1.0)
-	c := fyne.CurrentApp().Driver().CanvasForObject(t)
-	if c != nil {
-		scale = c.Scale()
+	f, ok := p.(*os.File)
+	if !ok { // supplied by a PTY factory
+		return
 	}
-	_ = pty.Setsize(t.pty.(*os.File), &pty.Winsize{
-		Rows: uint16(t.config.Rows), Cols: uint16(t.config.Columns),
-		X: uint16(t.Size().Width * scale), Y: uint16(t.Size().Height * scale)})
+	x, y := t.pixelSize()
+	_ = pty.Setsize(f, &pty.Winsize{
+		Rows: uint16(t.screen.config.Rows), Cols: uint16(t.screen.config.Columns), X: x, Y: y})
 }
 
 func (t *Terminal) startPTY() (io.WriteCloser, io.Reader, io.Closer, error) {
@@ -37,9 +38,11 @@ func (t *Terminal) startPTY() (io.WriteCloser, io.Reader, io.Closer, error) {
 	env = append(env, "TERM=xterm-256color")
 	c := exec.Command(shell)
 	c.Env = env
-	t.cmd = c
 
 	// Start the command with a pty.
 	f, err := pty.Start(c)
+	t.closeLock.Lock()
+	t.cmd = c
+	t.closeLock.Unlock()
 	return f, f, f, err
 }
@@ -11,10 +11,17 @@ import (
 )
 
 func (t *Terminal) updatePTYSize() {
-	if t.pty == nil { // during load
+	t.closeLock.Lock()
+	p := t.pty
+	t.closeLock.Unlock()
+	if p == nil { // during load
 		return
 	}
-	_ = t.pty.(*conpty.ConPty).Resize(uint16(t.config.Columns), uint16(t.config.Rows))
+	cpty, ok := p.(*conpty.ConPty)
+	if !ok { // supplied by a PTY factory
+		return
+	}
+	_ = cpty.Resize(uint16(t.screen.config.Columns), uint16(t.screen.config.Rows))
 }
 
 func (t *Terminal) startPTY() (io.WriteCloser, io.Reader, io.Closer, error) {
@@ -34,19 +41,24 @@ func (t *Terminal) startPTY() (io.WriteCloser, io.Reader, io.Closer, error) {
 		return nil, nil, nil, err
 	}
 
-	t.cmd = &exec.Cmd{}
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	t.closeLock.Lock()
+	t.cmd = &exec.Cmd{Process: process}
+	t.closeLock.Unlock()
 	go func() {
 		ps, err := process.Wait()
 		if err != nil {
 			log.Fatalf("Error waiting for process: %v", err)
 		}
-		t.cmd.ProcessState = ps
-		if t.pty != nil {
-			t.pty = nil
+		t.setExited(ps)
+		t.closeLock.Lock()
+		closePTY := t.pty != nil
+		t.pty = nil
+		t.closeLock.Unlock()
+		if closePTY {
 			_ = cpty.Close()
 		}
 	}()
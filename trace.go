@@ -0,0 +1,87 @@
+package terminal
+
+import (
+	"encoding/hex"
+	"io"
+
+	"fyne.io/fyne/v2"
+)
+
+// traceRing keeps the most recent bytes written to it, overwriting the oldest.
+type traceRing struct {
+	buf  []byte
+	next int
+	full bool
+}
+
+func (r *traceRing) write(data []byte) {
+	size := len(r.buf)
+	if len(data) >= size {
+		copy(r.buf, data[len(data)-size:])
+		r.next, r.full = 0, true
+		return
+	}
+
+	n := copy(r.buf[r.next:], data)
+	copy(r.buf, data[n:])
+	if r.next+len(data) >= size {
+		r.full = true
+	}
+	r.next = (r.next + len(data)) % size
+}
+
+func (r *traceRing) bytes() []byte {
+	if !r.full {
+		return append([]byte{}, r.buf[:r.next]...)
+	}
+	return append(append([]byte{}, r.buf[r.next:]...), r.buf[:r.next]...)
+}
+
+// SetTrace writes a hex dump of all output read from the connection to w, for debugging.
+// Pass nil to stop tracing.
+func (t *Terminal) SetTrace(w io.Writer) {
+	t.traceLock.Lock()
+	defer t.traceLock.Unlock()
+	t.traceOut = w
+}
+
+// EnableTraceRing keeps the last size bytes of output read from the connection in memory,
+// so that they can be retrieved with TraceDump, for example to attach to a bug report.
+// Passing 0 disables it and frees the memory.
+func (t *Terminal) EnableTraceRing(size int) {
+	t.traceLock.Lock()
+	defer t.traceLock.Unlock()
+	if size <= 0 {
+		t.traceRing = nil
+		return
+	}
+	t.traceRing = &traceRing{buf: make([]byte, size)}
+}
+
+// TraceDump returns a copy of the raw output kept since EnableTraceRing was called, oldest first.
+func (t *Terminal) TraceDump() []byte {
+	t.traceLock.Lock()
+	defer t.traceLock.Unlock()
+	if t.traceRing == nil {
+		return nil
+	}
+	return t.traceRing.bytes()
+}
+
+func (t *Terminal) trace(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	t.traceLock.Lock()
+	defer t.traceLock.Unlock()
+	if t.traceRing != nil {
+		t.traceRing.write(data)
+	}
+	if t.traceOut == nil {
+		return
+	}
+	if _, err := io.WriteString(t.traceOut, hex.Dump(data)); err != nil {
+		fyne.LogError("failed to write trace", err)
+	}
+}
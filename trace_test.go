@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrace(t *testing.T) {
+	term := New()
+	out := &bytes.Buffer{}
+	term.SetTrace(out)
+	term.trace([]byte("\x1b[1mhi"))
+	assert.Equal(t, hex.Dump([]byte("\x1b[1mhi")), out.String())
+
+	term.SetTrace(nil)
+	term.trace([]byte("more"))
+	assert.Equal(t, hex.Dump([]byte("\x1b[1mhi")), out.String())
+}
+
+func TestTraceRing(t *testing.T) {
+	term := New()
+	assert.Nil(t, term.TraceDump())
+
+	term.EnableTraceRing(8)
+	term.trace([]byte("abc"))
+	assert.Equal(t, "abc", string(term.TraceDump()))
+	term.trace([]byte("defgh"))
+	assert.Equal(t, "abcdefgh", string(term.TraceDump()))
+	term.trace([]byte("ijk"))
+	assert.Equal(t, "defghijk", string(term.TraceDump()))
+	term.trace([]byte("0123456789"))
+	assert.Equal(t, "23456789", string(term.TraceDump()))
+
+	term.EnableTraceRing(0)
+	assert.Nil(t, term.TraceDump())
+}
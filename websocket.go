@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const webSocketCloseTimeout = time.Second
+
+// WebSocketConn adapts a gorilla WebSocket so that a terminal can run over it, for example to
+// connect to a remote agent. Pass it as both arguments of RunWithConnection:
+//
+//	ws, _, _ := websocket.DefaultDialer.Dial("wss://example.com/term", nil)
+//	conn := terminal.NewWebSocketConn(ws)
+//	err := t.RunWithConnection(conn, conn)
+//
+// Input is sent as one binary message per write, and text or binary messages are read as output.
+// Pings are answered by the connection's ping handler and the session ends when the other end
+// closes the socket.
+type WebSocketConn struct {
+	ws *websocket.Conn
+
+	message   io.Reader  // the message being read, if any
+	writeLock sync.Mutex // a gorilla connection supports only one writer at a time
+}
+
+// NewWebSocketConn wraps the given WebSocket.
+func NewWebSocketConn(ws *websocket.Conn) *WebSocketConn {
+	return &WebSocketConn{ws: ws}
+}
+
+// Close sends a close message and closes the underlying connection.
+func (c *WebSocketConn) Close() error {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(webSocketCloseTimeout))
+	return c.ws.Close()
+}
+
+// Read returns the content of the messages received, a message may be split over multiple reads.
+// It returns io.EOF once the other end has closed the socket normally.
+func (c *WebSocketConn) Read(p []byte) (int, error) {
+	for {
+		if c.message == nil {
+			_, r, err := c.ws.NextReader()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway,
+				websocket.CloseNoStatusReceived) {
+				return 0, io.EOF
+			} else if err != nil {
+				return 0, err
+			}
+			c.message = r
+		}
+
+		n, err := c.message.Read(p)
+		if err == io.EOF {
+			c.message = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Write sends the data as a single binary message.
+func (c *WebSocketConn) Write(p []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
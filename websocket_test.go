@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebSocketConn(t *testing.T) {
+	messageType := make(chan int, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		kind, msg, _ := ws.ReadMessage()
+		messageType <- kind
+		_ = ws.WriteMessage(websocket.TextMessage, []byte("echo "))
+		_ = ws.WriteMessage(websocket.BinaryMessage, msg)
+		_ = ws.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		_, _, _ = ws.ReadMessage() // wait for the reply to our close
+	}))
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+	conn := NewWebSocketConn(ws)
+	defer conn.Close()
+
+	n, err := conn.Write([]byte("ls\r"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, websocket.BinaryMessage, <-messageType)
+
+	out, err := io.ReadAll(conn)
+	assert.Nil(t, err)
+	assert.Equal(t, "echo ls\r", string(out))
+}